@@ -0,0 +1,164 @@
+package microdata
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// ErrNoHTMLDocument is returned when an archive does not hold an HTML document.
+var ErrNoHTMLDocument = errors.New("microdata: archive has no HTML document")
+
+// ParseMHTML reads a web page saved as MHTML (a multipart/related MIME message) and returns the microdata of its main
+// HTML document. The main document is the part named by the "start" parameter, or the first HTML part. Its
+// Content-Location, or the Snapshot-Content-Location of the message, is used to resolve the URLs in the document.
+// The document is parsed with the given options.
+func ParseMHTML(r io.Reader, opts ...ParseOption) (*Record, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &Record{
+		URL: msg.Header.Get("Snapshot-Content-Location"),
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		record.Date = date
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		// A single part message holds the document itself.
+		body, err := decodeTransferEncoding(msg.Body, msg.Header.Get("Content-Transfer-Encoding"))
+		if err != nil {
+			return nil, err
+		}
+		record.ContentType = msg.Header.Get("Content-Type")
+		record.Microdata, record.Err = ParseHTML(body, record.ContentType, record.URL, opts...)
+		return record, nil
+	}
+
+	start := strings.Trim(params["start"], "<>")
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, ErrNoHTMLDocument
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		contentType := part.Header.Get("Content-Type")
+		partType, _, _ := mime.ParseMediaType(contentType)
+		contentID := strings.Trim(part.Header.Get("Content-ID"), "<>")
+		if (start != "" && contentID != start) || (start == "" && partType != "text/html") {
+			continue
+		}
+
+		// multipart.Reader already decodes quoted-printable parts.
+		body, err := decodeTransferEncoding(part, part.Header.Get("Content-Transfer-Encoding"))
+		if err != nil {
+			return nil, err
+		}
+		if location := part.Header.Get("Content-Location"); location != "" {
+			record.URL = location
+		}
+		record.ContentType = contentType
+		record.Microdata, record.Err = ParseHTML(body, contentType, record.URL, opts...)
+		return record, nil
+	}
+}
+
+// decodeTransferEncoding returns a reader decoding the given Content-Transfer-Encoding.
+func decodeTransferEncoding(r io.Reader, encoding string) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r), nil
+	case "", "7bit", "8bit", "binary", "quoted-printable":
+		return r, nil
+	default:
+		return nil, errors.New("microdata: unsupported transfer encoding " + encoding)
+	}
+}
+
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			StartedDateTime string `json:"startedDateTime"`
+			Request         struct {
+				URL string `json:"url"`
+			} `json:"request"`
+			Response struct {
+				Status  int `json:"status"`
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+				Content struct {
+					MimeType string `json:"mimeType"`
+					Text     string `json:"text"`
+					Encoding string `json:"encoding"`
+				} `json:"content"`
+			} `json:"response"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// ParseHAR reads an HTTP Archive (HAR) file, e.g. exported from the browser's developer tools, and calls fn with
+// the microdata of every entry whose response is an HTML document. The request URL of an entry is used to resolve the
+// URLs in its document, which is parsed with the given options.
+func ParseHAR(r io.Reader, fn func(Record), opts ...ParseOption) error {
+	var har harFile
+	if err := json.NewDecoder(r).Decode(&har); err != nil {
+		return err
+	}
+
+	for _, entry := range har.Log.Entries {
+		content := entry.Response.Content
+		contentType := content.MimeType
+		for _, h := range entry.Response.Headers {
+			if strings.EqualFold(h.Name, "Content-Type") && h.Value != "" {
+				contentType = h.Value
+			}
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+			continue
+		}
+
+		record := Record{
+			URL:         entry.Request.URL,
+			StatusCode:  entry.Response.Status,
+			ContentType: contentType,
+		}
+		if date, err := time.Parse(time.RFC3339Nano, entry.StartedDateTime); err == nil {
+			record.Date = date
+		}
+
+		// Text content has already been decoded to Unicode, only base64 content keeps the original encoding.
+		text, parseType := []byte(content.Text), "text/html; charset=utf-8"
+		if content.Encoding == "base64" {
+			if text, record.Err = base64.StdEncoding.DecodeString(content.Text); record.Err != nil {
+				fn(record)
+				continue
+			}
+			parseType = contentType
+		}
+
+		record.Microdata, record.Err = ParseHTML(bytes.NewReader(text), parseType, record.URL, opts...)
+		fn(record)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package microdata
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var mhtmlSnippet = "From: <Saved by Blink>\r\n" +
+	"Snapshot-Content-Location: https://shop.example.com/p/42\r\n" +
+	"Subject: Product\r\n" +
+	"Date: Wed, 1 May 2024 12:00:00 +0000\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/related;\r\n\ttype=\"text/html\";\r\n\tboundary=\"----MultipartBoundary--abc\"\r\n" +
+	"\r\n" +
+	"------MultipartBoundary--abc\r\n" +
+	"Content-Type: text/css\r\n" +
+	"Content-Location: https://shop.example.com/style.css\r\n" +
+	"\r\n" +
+	"body { color: red; }\r\n" +
+	"------MultipartBoundary--abc\r\n" +
+	"Content-Type: text/html\r\n" +
+	"Content-ID: <frame-1@mhtml.blink>\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"Content-Location: https://shop.example.com/p/42\r\n" +
+	"\r\n" +
+	"<html><head><meta charset=3D\"utf-8\"></head><body><div itemscope itemtype=3D\"https://schema.org/Product\">=\r\n" +
+	"<span itemprop=3D\"name\">Caf=C3=A9 table</span><img itemprop=3D\"image\" src=3D\"/img/42.jpg\"></div>=\r\n" +
+	"</body></html>\r\n" +
+	"------MultipartBoundary--abc--\r\n"
+
+func TestParseMHTML(t *testing.T) {
+	record, err := ParseMHTML(strings.NewReader(mhtmlSnippet))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Err != nil {
+		t.Fatal(record.Err)
+	}
+
+	if record.URL != "https://shop.example.com/p/42" {
+		t.Errorf("Result should have been \"https://shop.example.com/p/42\", but it was \"%s\"", record.URL)
+	}
+
+	item := record.Microdata.Items[0]
+	if result := item.Properties["name"][0].(string); result != "Café table" {
+		t.Errorf("Result should have been \"Café table\", but it was \"%s\"", result)
+	}
+	if result := item.Properties["image"][0].(string); result != "https://shop.example.com/img/42.jpg" {
+		t.Errorf("Result should have been \"https://shop.example.com/img/42.jpg\", but it was \"%s\"", result)
+	}
+}
+
+func TestParseMHTMLOptions(t *testing.T) {
+	record, err := ParseMHTML(strings.NewReader(mhtmlSnippet), WithEncoding("windows-1252"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Err != nil {
+		t.Fatal(record.Err)
+	}
+	name, _ := record.Microdata.Items[0].GetProperty("name")
+	if expected := "CafÃ© table"; name != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%v\"", expected, name)
+	}
+}
+
+func TestParseHAR(t *testing.T) {
+	page := `<div itemscope itemtype="https://schema.org/Article"><a itemprop="url" href="story">Story</a></div>`
+	har := fmt.Sprintf(`{"log": {"version": "1.2", "entries": [
+		{"startedDateTime": "2024-05-01T12:00:00.000Z", "request": {"url": "https://news.example.com/"},
+		 "response": {"status": 200, "content": {"mimeType": "text/html; charset=windows-1252", "text": %q}}},
+		{"request": {"url": "https://news.example.com/app.js"},
+		 "response": {"status": 200, "content": {"mimeType": "application/javascript", "text": "var a;"}}},
+		{"request": {"url": "https://news.example.com/b/"},
+		 "response": {"status": 200, "headers": [{"name": "content-type", "value": "text/html"}],
+		  "content": {"mimeType": "text/html", "text": %q, "encoding": "base64"}}}
+	]}}`, page, base64.StdEncoding.EncodeToString([]byte(page)))
+
+	var urls []string
+	err := ParseHAR(strings.NewReader(har), func(r Record) {
+		if r.Err != nil {
+			t.Error(r.Err)
+			return
+		}
+		urls = append(urls, r.Microdata.Items[0].Properties["url"][0].(string))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "https://news.example.com/story https://news.example.com/b/story"
+	if result := strings.Join(urls, " "); result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
@@ -0,0 +1,239 @@
+package microdata
+
+import (
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// backcompatRoots maps the classic microformats root class names to their microformats2 types, see
+// https://microformats.org/wiki/microformats2-parsing#parsing_for_backcompat.
+var backcompatRoots = map[string]string{
+	"adr":               "h-adr",
+	"geo":               "h-geo",
+	"hentry":            "h-entry",
+	"hfeed":             "h-feed",
+	"hproduct":          "h-product",
+	"hrecipe":           "h-recipe",
+	"hresume":           "h-resume",
+	"hreview":           "h-review",
+	"hreview-aggregate": "h-review-aggregate",
+	"vcard":             "h-card",
+	"vevent":            "h-event",
+}
+
+// Pseudo class names of the backcompat properties derived from rel attributes.
+const (
+	backcompatRelTag      = "rel=tag"
+	backcompatRelBookmark = "rel=bookmark"
+)
+
+// backcompatProperties maps the classic property class names of each microformats2 type to their microformats2
+// properties.
+var backcompatProperties = map[string]map[string]mfProperty{
+	"h-adr": {
+		"post-office-box":  {"p", "post-office-box"},
+		"extended-address": {"p", "extended-address"},
+		"street-address":   {"p", "street-address"},
+		"locality":         {"p", "locality"},
+		"region":           {"p", "region"},
+		"postal-code":      {"p", "postal-code"},
+		"country-name":     {"p", "country-name"},
+	},
+	"h-geo": {
+		"latitude":  {"p", "latitude"},
+		"longitude": {"p", "longitude"},
+	},
+	"h-card": {
+		"fn":                {"p", "name"},
+		"honorific-prefix":  {"p", "honorific-prefix"},
+		"given-name":        {"p", "given-name"},
+		"additional-name":   {"p", "additional-name"},
+		"family-name":       {"p", "family-name"},
+		"honorific-suffix":  {"p", "honorific-suffix"},
+		"nickname":          {"p", "nickname"},
+		"email":             {"u", "email"},
+		"logo":              {"u", "logo"},
+		"photo":             {"u", "photo"},
+		"url":               {"u", "url"},
+		"uid":               {"u", "uid"},
+		"category":          {"p", "category"},
+		"adr":               {"p", "adr"},
+		"extended-address":  {"p", "extended-address"},
+		"street-address":    {"p", "street-address"},
+		"locality":          {"p", "locality"},
+		"region":            {"p", "region"},
+		"postal-code":       {"p", "postal-code"},
+		"country-name":      {"p", "country-name"},
+		"label":             {"p", "label"},
+		"geo":               {"p", "geo"},
+		"latitude":          {"p", "latitude"},
+		"longitude":         {"p", "longitude"},
+		"tel":               {"p", "tel"},
+		"note":              {"p", "note"},
+		"bday":              {"dt", "bday"},
+		"key":               {"u", "key"},
+		"org":               {"p", "org"},
+		"organization-name": {"p", "organization-name"},
+		"organization-unit": {"p", "organization-unit"},
+		"title":             {"p", "job-title"},
+		"role":              {"p", "role"},
+	},
+	"h-entry": {
+		"entry-title":         {"p", "name"},
+		"entry-summary":       {"p", "summary"},
+		"entry-content":       {"e", "content"},
+		"published":           {"dt", "published"},
+		"updated":             {"dt", "updated"},
+		"author":              {"p", "author"},
+		"category":            {"p", "category"},
+		"geo":                 {"p", "geo"},
+		"latitude":            {"p", "latitude"},
+		"longitude":           {"p", "longitude"},
+		backcompatRelTag:      {"p", "category"},
+		backcompatRelBookmark: {"u", "url"},
+	},
+	"h-feed": {
+		"author":         {"p", "author"},
+		"photo":          {"u", "photo"},
+		"url":            {"u", "url"},
+		backcompatRelTag: {"p", "category"},
+	},
+	"h-event": {
+		"summary":     {"p", "name"},
+		"dtstart":     {"dt", "start"},
+		"dtend":       {"dt", "end"},
+		"duration":    {"dt", "duration"},
+		"description": {"p", "description"},
+		"url":         {"u", "url"},
+		"category":    {"p", "category"},
+		"location":    {"p", "location"},
+		"geo":         {"p", "location"},
+		"attendee":    {"p", "attendee"},
+		"contact":     {"p", "contact"},
+		"organizer":   {"p", "organizer"},
+	},
+	"h-product": {
+		"fn":          {"p", "name"},
+		"photo":       {"u", "photo"},
+		"brand":       {"p", "brand"},
+		"category":    {"p", "category"},
+		"description": {"p", "description"},
+		"identifier":  {"u", "identifier"},
+		"url":         {"u", "url"},
+		"review":      {"p", "review"},
+		"price":       {"p", "price"},
+	},
+	"h-recipe": {
+		"fn":           {"p", "name"},
+		"ingredient":   {"p", "ingredient"},
+		"yield":        {"p", "yield"},
+		"instructions": {"e", "instructions"},
+		"duration":     {"dt", "duration"},
+		"photo":        {"u", "photo"},
+		"summary":      {"p", "summary"},
+		"author":       {"p", "author"},
+		"published":    {"dt", "published"},
+		"nutrition":    {"p", "nutrition"},
+		"category":     {"p", "category"},
+	},
+	"h-resume": {
+		"summary":     {"p", "summary"},
+		"contact":     {"p", "contact"},
+		"education":   {"p", "education"},
+		"experience":  {"p", "experience"},
+		"skill":       {"p", "skill"},
+		"affiliation": {"p", "affiliation"},
+	},
+	"h-review": {
+		"summary":             {"p", "name"},
+		"fn":                  {"p", "name"},
+		"item":                {"p", "item"},
+		"reviewer":            {"p", "author"},
+		"dtreviewed":          {"dt", "published"},
+		"rating":              {"p", "rating"},
+		"best":                {"p", "best"},
+		"worst":               {"p", "worst"},
+		"description":         {"e", "content"},
+		backcompatRelTag:      {"p", "category"},
+		backcompatRelBookmark: {"u", "url"},
+	},
+	"h-review-aggregate": {
+		"summary": {"p", "name"},
+		"fn":      {"p", "name"},
+		"item":    {"p", "item"},
+		"rating":  {"p", "rating"},
+		"average": {"p", "average"},
+		"best":    {"p", "best"},
+		"worst":   {"p", "worst"},
+		"count":   {"p", "count"},
+		"votes":   {"p", "votes"},
+	},
+}
+
+// backcompatTypes returns the sorted microformats2 types of the classic root class names of the element.
+func backcompatTypes(n *html.Node) []string {
+	var types []string
+	for _, class := range classNames(n) {
+		if t, ok := backcompatRoots[class]; ok && !containsString(types, t) {
+			types = append(types, t)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// backcompatMapping returns the classic property class names of the given types.
+func backcompatMapping(types []string) map[string]mfProperty {
+	mapping := make(map[string]mfProperty)
+	for _, t := range types {
+		for class, prop := range backcompatProperties[t] {
+			if _, ok := mapping[class]; !ok {
+				mapping[class] = prop
+			}
+		}
+	}
+	return mapping
+}
+
+// backcompatPropertiesOf returns the microformats2 properties of the classic property class names and rel values
+// of the element.
+func backcompatPropertiesOf(n *html.Node, mapping map[string]mfProperty) []mfProperty {
+	var props []mfProperty
+	add := func(prop mfProperty) {
+		if !containsProperty(props, prop) {
+			props = append(props, prop)
+		}
+	}
+
+	for _, class := range classNames(n) {
+		if prop, ok := mapping[class]; ok {
+			add(prop)
+		}
+	}
+	if rel, ok := getAttr("rel", n); ok {
+		for _, r := range strings.Fields(strings.ToLower(rel)) {
+			if prop, ok := mapping["rel="+r]; ok {
+				add(prop)
+			}
+		}
+	}
+	return props
+}
+
+// relTagValue returns the category of a rel=tag link: the last segment of its URL path, decoded.
+func (mp *mfParser) relTagValue(n *html.Node) string {
+	href, _ := getAttr("href", n)
+	u, err := url.Parse(mp.resolve(href))
+	if err != nil {
+		return mp.text(n)
+	}
+	tag := path.Base(strings.TrimSuffix(u.Path, "/"))
+	if tag == "." || tag == "/" {
+		return mp.text(n)
+	}
+	return tag
+}
@@ -0,0 +1,138 @@
+package microdata
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrNotCached is returned by a Fetcher with an offline cache for documents that are not in the cache.
+var ErrNotCached = errors.New("microdata: document not in cache")
+
+// Cache is an on-disk HTTP cache for a Fetcher. It stores response bodies with their ETag and Last-Modified values
+// and revalidates them with If-None-Match and If-Modified-Since requests. A 304 Not Modified response is served
+// from the stored body.
+type Cache struct {
+	// Dir is the directory holding the cached documents.
+	Dir string
+	// Offline serves documents from the cache only, without sending any request.
+	Offline bool
+}
+
+// cacheEntry is the metadata stored next to a cached body.
+type cacheEntry struct {
+	URL        string      `json:"url"`
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Redirects  []Redirect  `json:"redirects,omitempty"`
+	StoredAt   time.Time   `json:"storedAt"`
+}
+
+// NewCache returns a cache storing its documents in the given directory, which is created if needed.
+func NewCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Cache{Dir: dir}, nil
+}
+
+// path returns the path of the cache file with the given extension for the requested URL.
+func (c *Cache) path(urlStr, ext string) string {
+	sum := sha256.Sum256([]byte(urlStr))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(c.Dir, key[:2], key+ext)
+}
+
+// load returns the entry stored for the requested URL, or nil when there is none.
+func (c *Cache) load(urlStr string) *cacheEntry {
+	b, err := os.ReadFile(c.path(urlStr, ".json"))
+	if err != nil {
+		return nil
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return nil
+	}
+	if _, err := os.Stat(c.path(urlStr, ".body")); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+// open returns the response and body stored for the requested URL.
+func (c *Cache) open(urlStr string, entry *cacheEntry) (*Response, io.ReadCloser, error) {
+	resp := &Response{
+		URL:         entry.URL,
+		StatusCode:  entry.StatusCode,
+		Header:      entry.Header,
+		ContentType: entry.Header.Get("Content-Type"),
+		Redirects:   entry.Redirects,
+		Cached:      true,
+	}
+
+	f, err := os.Open(c.path(urlStr, ".body"))
+	if err != nil {
+		return nil, nil, resp.error(err)
+	}
+	return resp, f, nil
+}
+
+// store writes the response and its body for the requested URL.
+func (c *Cache) store(urlStr string, resp *Response, body []byte) error {
+	entry := cacheEntry{
+		URL:        resp.URL,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Redirects:  resp.Redirects,
+		StoredAt:   time.Now().UTC(),
+	}
+	meta, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// The body goes first so that a stored entry always has one.
+	if err := writeFileAtomic(c.path(urlStr, ".body"), body); err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path(urlStr, ".json"), meta)
+}
+
+// addValidators adds the conditional request headers for the entry to the header.
+func (e *cacheEntry) addValidators(header http.Header) {
+	if etag := e.Header.Get("ETag"); etag != "" {
+		header.Set("If-None-Match", etag)
+	}
+	if lastModified := e.Header.Get("Last-Modified"); lastModified != "" {
+		header.Set("If-Modified-Since", lastModified)
+	}
+}
+
+// writeFileAtomic writes the data to a temporary file and renames it to the given path.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
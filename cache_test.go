@@ -0,0 +1,61 @@
+package microdata
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetcherCache(t *testing.T) {
+	requests, notModified := 0, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<div itemscope itemtype="https://schema.org/Thing"><span itemprop="name">Cached</span></div>`))
+	}))
+	defer ts.Close()
+
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher := NewFetcher(WithCache(cache))
+
+	for i, expectedCached := range []bool{false, true} {
+		resp, err := fetcher.Fetch(context.Background(), ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Cached != expectedCached {
+			t.Errorf("Request %d: Cached should have been %v, but it was %v", i, expectedCached, resp.Cached)
+		}
+		if result := resp.Microdata.Items[0].Properties["name"][0].(string); result != "Cached" {
+			t.Errorf("Result should have been \"Cached\", but it was \"%s\"", result)
+		}
+	}
+
+	if requests != 2 || notModified != 1 {
+		t.Errorf("Result should have been 2 requests with 1 revalidation, but it was %d with %d", requests, notModified)
+	}
+
+	offline := NewFetcher(WithCache(&Cache{Dir: cache.Dir, Offline: true}))
+	data, err := offline.ParseURL(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Items) != 1 || requests != 2 {
+		t.Errorf("Result should have been 1 item without requests, but it was %d items with %d requests", len(data.Items), requests-2)
+	}
+
+	if _, err := offline.ParseURL(context.Background(), ts.URL+"/other"); !errors.Is(err, ErrNotCached) {
+		t.Errorf("Result should have been \"%v\", but it was \"%v\"", ErrNotCached, err)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -20,13 +21,20 @@ func main() {
 
 	baseURL := flag.String("base-url", "https://example.com", "base url to use for the data in the stdin stream.")
 	contentType := flag.String("content-type", "", "content type of the data in the stdin stream.")
+	encoding := flag.String("encoding", "", "character encoding of the document, e.g. shift_jis. Detected when empty.")
+	input := flag.String("input", "html", "format of the input: html|warc|mhtml|har. Archives are read from the file arguments or stdin.")
 	format := flag.String("format", "{{. |jsonMarshal }}", `alternate format for the output of the
 	microdata, using the syntax of package html/template. The default output is
 	equivalent to -f '{{. |jsonMarshal }}'. The struct being passed to the
 	template is:
 		
-		type Microdata struct
-			Items []*Item 'json:"items"'
+		type Microdata struct {
+			Items        []*Item           'json:"items"'
+			Metadata     *DocumentMetadata 'json:"metadata,omitempty"'
+			OpenGraph    *OpenGraph        'json:"openGraph,omitempty"'
+			Twitter      *TwitterCard      'json:"twitter,omitempty"'
+			Microformats *Microformats     'json:"microformats,omitempty"'
+			OEmbed       []OEmbedEndpoint  'json:"oembed,omitempty"'
 		}
 
 		type Item struct {
@@ -39,8 +47,10 @@ func main() {
 		
 		type ValueList []interface{}
 
-	The template function "jsonMarshal" calls json.Marshal
+	The template function "jsonMarshal" calls json.Marshal, which only writes
+	the page-level metadata, i.e. the fields after Items, with -metadata.
 `)
+	metadata := flag.Bool("metadata", false, "write the page metadata, Open Graph, Twitter card, microformats and oEmbed endpoints in the JSON output.")
 
 	flag.Usage = func() {
 		_, _ = fmt.Fprintf(os.Stderr, "Usage of %s [options] [url]:\n", os.Args[0])
@@ -51,29 +61,106 @@ func main() {
 
 	flag.Parse()
 
+	t := template.Must(template.New("format").Funcs(fnMap).Parse(*format))
+
+	var opts []microdata.ParseOption
+	if *encoding != "" {
+		opts = append(opts, microdata.WithEncoding(*encoding))
+	}
+	if *metadata {
+		opts = append(opts, microdata.WithMetadataJSON())
+	}
+
+	if *input != "html" {
+		if err := parseArchives(*input, flag.Args(), t, opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Fetch and parse microdata
 	switch len(flag.Args()) {
 	case 0:
-		data, err = microdata.ParseHTML(os.Stdin, *contentType, *baseURL)
+		data, err = microdata.ParseHTML(os.Stdin, *contentType, *baseURL, opts...)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
 	default:
-		data, err = microdata.ParseURL(flag.Args()[0])
+		data, err = microdata.ParseURLContext(context.Background(), flag.Args()[0], microdata.WithParseOptions(opts...))
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
 	}
 
-	t := template.Must(template.New("format").Funcs(fnMap).Parse(*format))
 	if err := t.Execute(os.Stdout, data); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 }
 
+// parseArchives reads the archives of the given format from the given files, or stdin when there are none, and
+// writes every record using the template. The documents are parsed with the given options.
+func parseArchives(input string, files []string, t *template.Template, opts []microdata.ParseOption) error {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	for _, file := range files {
+		if err := parseArchive(input, file, t, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseArchive reads the archive of the given format from the given file, or stdin for "-", and writes every record
+// using the template.
+func parseArchive(input, file string, t *template.Template, opts []microdata.ParseOption) error {
+	r := os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var execErr error
+	fn := func(record microdata.Record) {
+		if record.Err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", record.URL, record.Err)
+			return
+		}
+		if execErr == nil {
+			execErr = t.Execute(os.Stdout, record)
+			fmt.Println()
+		}
+	}
+
+	var err error
+	switch input {
+	case "warc":
+		err = microdata.ParseWARC(r, fn, opts...)
+	case "mhtml":
+		var record *microdata.Record
+		if record, err = microdata.ParseMHTML(r, opts...); err == nil {
+			fn(*record)
+		}
+	case "har":
+		err = microdata.ParseHAR(r, fn, opts...)
+	default:
+		err = fmt.Errorf("unknown input format %q", input)
+	}
+	if err != nil {
+		return err
+	}
+	return execErr
+}
+
 // jsonMarshal encodes the given data to JSON.
 func jsonMarshal(data interface{}) (string, error) {
 	b, err := json.MarshalIndent(data, "", "  ")
@@ -0,0 +1,351 @@
+package microdata
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// DefaultCrawlDelay is the minimum time between two requests to the same host unless configured otherwise.
+const DefaultCrawlDelay = time.Second
+
+// DefaultCrawlWorkers is the number of pages fetched concurrently by a Crawler unless configured.
+const DefaultCrawlWorkers = 4
+
+// ErrDisallowedByRobots is returned for pages that the host's robots.txt does not allow to crawl.
+var ErrDisallowedByRobots = errors.New("microdata: disallowed by robots.txt")
+
+// Crawler extracts the microdata of the pages of a site. Starting from seed URLs it follows the <a href> links to
+// pages on the same hosts as the seeds, up to a maximum depth, obeying robots.txt and a per-host rate limit.
+type Crawler struct {
+	// Fetcher fetches pages and robots.txt files. NewFetcher() is used when nil.
+	Fetcher *Fetcher
+	// MaxDepth is the number of links followed from the seeds. Only the seeds are crawled when 0.
+	MaxDepth int
+	// MaxPages limits the number of pages crawled. There is no limit when <= 0.
+	MaxPages int
+	// Workers is the number of pages fetched concurrently. DefaultCrawlWorkers is used when <= 0.
+	Workers int
+	// Delay is the minimum time between two requests to the same host. A longer robots.txt Crawl-delay takes
+	// precedence. DefaultCrawlDelay is used when 0, no delay is applied when < 0.
+	Delay time.Duration
+	// IgnoreRobots disables fetching and obeying robots.txt.
+	IgnoreRobots bool
+}
+
+// CrawlResult is the outcome of crawling a single page.
+type CrawlResult struct {
+	// URL is the normalized URL that was requested.
+	URL string
+	// Depth is the number of links followed from a seed to reach the page.
+	Depth int
+	// Response holds the fetched document's metadata and microdata.
+	Response *Response
+	// Links are the normalized same-site links found on the page.
+	Links []string
+	Err   error
+}
+
+type crawlJob struct {
+	url   string
+	depth int
+}
+
+// Crawl crawls the site starting from the given seed URLs and streams the result of every page over the returned
+// channel. The channel is closed when there are no more pages to crawl or ctx is canceled.
+func (c *Crawler) Crawl(ctx context.Context, seeds ...string) <-chan CrawlResult {
+	fetcher := c.Fetcher
+	if fetcher == nil {
+		fetcher = NewFetcher()
+	}
+	workers := c.Workers
+	if workers <= 0 {
+		workers = DefaultCrawlWorkers
+	}
+	delay := c.Delay
+	if delay == 0 {
+		delay = DefaultCrawlDelay
+	}
+
+	s := &crawlSession{
+		ctx:     ctx,
+		crawler: c,
+		fetcher: fetcher,
+		delay:   delay,
+		hosts:   make(map[string]*crawlHost),
+	}
+
+	results := make(chan CrawlResult)
+	go s.run(seeds, workers, results)
+	return results
+}
+
+// crawlSession holds the state of a single crawl.
+type crawlSession struct {
+	ctx     context.Context
+	crawler *Crawler
+	fetcher *Fetcher
+	delay   time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*crawlHost
+}
+
+// crawlHost holds the robots.txt rules and the rate limit of a single host.
+type crawlHost struct {
+	once   sync.Once
+	robots *robotsRules
+	next   time.Time
+}
+
+// run schedules the pages breadth first over the workers until the frontier is exhausted.
+func (s *crawlSession) run(seeds []string, workers int, results chan<- CrawlResult) {
+	defer close(results)
+
+	jobs := make(chan crawlJob)
+	outcomes := make(chan CrawlResult)
+	defer close(jobs)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range jobs {
+				result := s.crawl(job)
+				select {
+				case outcomes <- result:
+				case <-s.ctx.Done():
+				}
+			}
+		}()
+	}
+
+	seen := make(map[string]bool)
+	allowedHosts := make(map[string]bool)
+	var queue []crawlJob
+	enqueue := func(u string, depth int) {
+		if seen[u] || (s.crawler.MaxPages > 0 && len(seen) >= s.crawler.MaxPages) {
+			return
+		}
+		seen[u] = true
+		queue = append(queue, crawlJob{url: u, depth: depth})
+	}
+
+	for _, seed := range seeds {
+		u, err := normalizeURL(nil, seed)
+		if err != nil {
+			select {
+			case results <- CrawlResult{URL: seed, Err: err}:
+			case <-s.ctx.Done():
+				return
+			}
+			continue
+		}
+		allowedHosts[u.Host] = true
+		enqueue(u.String(), 0)
+	}
+
+	pending := 0
+	for len(queue) > 0 || pending > 0 {
+		var send chan<- crawlJob
+		var next crawlJob
+		if len(queue) > 0 {
+			send, next = jobs, queue[0]
+		}
+
+		select {
+		case send <- next:
+			queue = queue[1:]
+			pending++
+		case result := <-outcomes:
+			pending--
+			if result.Depth < s.crawler.MaxDepth {
+				for _, link := range result.Links {
+					if u, err := url.Parse(link); err == nil && allowedHosts[u.Host] {
+						enqueue(link, result.Depth+1)
+					}
+				}
+			}
+			select {
+			case results <- result:
+			case <-s.ctx.Done():
+				return
+			}
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// crawl fetches a single page and extracts its microdata and links from the same parsed tree.
+func (s *crawlSession) crawl(job crawlJob) CrawlResult {
+	result := CrawlResult{URL: job.url, Depth: job.depth}
+
+	u, err := url.Parse(job.url)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	host := s.host(u)
+	if !host.robots.allowed(u.RequestURI()) {
+		result.Err = ErrDisallowedByRobots
+		return result
+	}
+	if err := s.wait(host); err != nil {
+		result.Err = err
+		return result
+	}
+
+	resp, body, err := s.fetcher.open(s.ctx, job.url)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer body.Close()
+
+	tree, enc, err := parseDocument(body, resp.ContentType, s.fetcher.ParseOptions)
+	if err != nil {
+		result.Err = resp.error(err)
+		return result
+	}
+
+	resp.Microdata, err = ParseNode(tree, resp.URL, s.fetcher.ParseOptions...)
+	if err != nil {
+		result.Err = resp.error(err)
+		return result
+	}
+	resp.Microdata.Encoding = enc
+	result.Response = resp
+
+	base, _ := url.Parse(resp.Microdata.BaseURL)
+	result.Links = findLinks(tree, base)
+	return result
+}
+
+// host returns the state of the host of the given URL, reading its robots.txt on first use.
+func (s *crawlSession) host(u *url.URL) *crawlHost {
+	s.mu.Lock()
+	h, ok := s.hosts[u.Host]
+	if !ok {
+		h = &crawlHost{}
+		s.hosts[u.Host] = h
+	}
+	s.mu.Unlock()
+
+	h.once.Do(func() {
+		h.robots = allowAll
+		if s.crawler.IgnoreRobots {
+			return
+		}
+
+		robotsURL := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+		_, body, err := s.fetcher.get(s.ctx, robotsURL.String())
+		if err != nil {
+			// RFC 9309: an unavailable robots.txt (4xx) allows everything, an unreachable one disallows everything.
+			var fetchErr *FetchError
+			if errors.As(err, &fetchErr) && fetchErr.StatusCode >= 400 && fetchErr.StatusCode < 500 {
+				return
+			}
+			h.robots = disallowAll
+			return
+		}
+		defer body.Close()
+		h.robots = parseRobots(body, s.fetcher.Header.Get("User-Agent"))
+	})
+	return h
+}
+
+// wait blocks until the host's rate limit allows another request.
+func (s *crawlSession) wait(h *crawlHost) error {
+	delay := s.delay
+	if h.robots.crawlDelay > delay {
+		delay = h.robots.crawlDelay
+	}
+
+	s.mu.Lock()
+	now := time.Now()
+	at := h.next
+	if at.Before(now) {
+		at = now
+	}
+	if delay > 0 {
+		h.next = at.Add(delay)
+	}
+	s.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+// findLinks returns the normalized, de-duplicated http(s) URLs of the <a> and <area> links in the tree.
+func findLinks(root *html.Node, base *url.URL) []string {
+	var links []string
+	seen := make(map[string]bool)
+	walkNodes(root, func(n *html.Node) {
+		if n.Type != html.ElementNode || (n.DataAtom != atom.A && n.DataAtom != atom.Area) {
+			return
+		}
+		href, ok := getAttr("href", n)
+		if !ok {
+			return
+		}
+		if rel, _ := getAttr("rel", n); containsToken(rel, "nofollow") {
+			return
+		}
+
+		u, err := normalizeURL(base, href)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+		if s := u.String(); !seen[s] {
+			seen[s] = true
+			links = append(links, s)
+		}
+	})
+	return links
+}
+
+// normalizeURL resolves the reference against base and normalizes it for de-duplication: the scheme and host are
+// lowercased, default ports and the fragment are dropped and an empty path becomes "/".
+func normalizeURL(base *url.URL, ref string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return nil, err
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if (u.Scheme == "http" && u.Port() == "80") || (u.Scheme == "https" && u.Port() == "443") {
+		u.Host = u.Hostname()
+	}
+	if u.Path == "" && u.Opaque == "" {
+		u.Path = "/"
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u, nil
+}
+
+// containsToken returns true if the space separated list contains the token, ignoring case.
+func containsToken(list, token string) bool {
+	for _, t := range strings.Fields(list) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package microdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRobots(t *testing.T) {
+	robots := `
+User-agent: Googlebot
+Disallow: /
+
+User-agent: microdata
+User-agent: otherbot
+Disallow: /private
+Allow: /private/open$
+Disallow: /*.pdf$
+Crawl-delay: 2.5
+
+User-agent: *
+Disallow: /nothing-for-others
+`
+
+	rules := parseRobots(strings.NewReader(robots), DefaultUserAgent)
+
+	var testTable = []struct {
+		path     string
+		expected bool
+	}{
+		{"/", true},
+		{"/private", false},
+		{"/private/page", false},
+		{"/private/open", true},
+		{"/private/open/more", false},
+		{"/files/report.pdf", false},
+		{"/files/report.pdf?download=1", true},
+		{"/nothing-for-others", true},
+		{"/robots.txt", true},
+	}
+
+	for _, test := range testTable {
+		if result := rules.allowed(test.path); result != test.expected {
+			t.Errorf("Result for \"%s\" should have been %v, but it was %v", test.path, test.expected, result)
+		}
+	}
+
+	if rules.crawlDelay != 2500*time.Millisecond {
+		t.Errorf("Result should have been \"2.5s\", but it was \"%s\"", rules.crawlDelay)
+	}
+
+	rules = parseRobots(strings.NewReader(robots), "somebot/2.0")
+	if rules.allowed("/nothing-for-others") || !rules.allowed("/private") {
+		t.Error("Result should have used the rules of the \"*\" group")
+	}
+}
+
+func TestCrawler(t *testing.T) {
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /secret\n")
+	})
+	page := func(path, name, body string) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `<html><body><div itemscope itemtype="https://schema.org/WebPage"><span itemprop="name">%s</span></div>%s</body></html>`, name, body)
+		})
+	}
+	page("/", "home", `<a href="/a">A</a> <a href="/a#top">A again</a> <a href="https://other.example.com/">Other</a>`)
+	page("/a", "a", `<a href="b">B</a> <a href="/secret">Secret</a> <a href="/ignored" rel="nofollow">Ignored</a>`)
+	page("/b", "b", `<a href="/c">C</a>`)
+	page("/c", "c", ``)
+	page("/secret", "secret", ``)
+	page("/ignored", "ignored", ``)
+
+	crawler := &Crawler{MaxDepth: 2, Delay: -1}
+
+	var pages []string
+	var disallowed []string
+	for result := range crawler.Crawl(context.Background(), ts.URL) {
+		path := strings.TrimPrefix(result.URL, ts.URL)
+		switch {
+		case errors.Is(result.Err, ErrDisallowedByRobots):
+			disallowed = append(disallowed, path)
+		case result.Err != nil:
+			t.Errorf("Crawling \"%s\" failed: %v", path, result.Err)
+		default:
+			name := result.Response.Microdata.Items[0].Properties["name"][0].(string)
+			pages = append(pages, fmt.Sprintf("%s:%d", name, result.Depth))
+		}
+	}
+	sort.Strings(pages)
+
+	if expected := "a:1 b:2 home:0"; strings.Join(pages, " ") != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, strings.Join(pages, " "))
+	}
+	if expected := "/secret"; strings.Join(disallowed, " ") != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, strings.Join(disallowed, " "))
+	}
+}
+
+func TestCrawlerCrawlDelay(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nCrawl-delay: 0.1\n")
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<a href="/1">1</a><a href="/2">2</a>`)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	start := time.Now()
+	count := 0
+	for result := range (&Crawler{MaxDepth: 1, Workers: 3, Delay: -1}).Crawl(context.Background(), ts.URL) {
+		if result.Err != nil {
+			t.Error(result.Err)
+		}
+		count++
+	}
+
+	if count != 3 {
+		t.Errorf("Result should have been 3 pages, but it was %d", count)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("Crawl should have taken at least 200ms, but it took %s", elapsed)
+	}
+}
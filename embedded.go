@@ -0,0 +1,390 @@
+package microdata
+
+import (
+	"log"
+	"mime"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/astappiev/fixjson"
+	"golang.org/x/net/html"
+)
+
+// Origins of the items found in embedded application state.
+const (
+	// OriginNextData marks items found in the <script id="__NEXT_DATA__"> state of Next.js.
+	OriginNextData = "next-data"
+	// OriginNuxt marks items found in the window.__NUXT__ state of Nuxt.
+	OriginNuxt = "nuxt"
+	// OriginApollo marks items found in the window.__APOLLO_STATE__ cache of Apollo Client.
+	OriginApollo = "apollo"
+	// OriginAppState marks items found in other window.__*__ state assignments, e.g. window.__INITIAL_STATE__.
+	OriginAppState = "app-state"
+	// OriginJSON marks items found in other <script type="application/json"> elements.
+	OriginJSON = "application/json"
+)
+
+// EmbeddedJSONDetector tells whether an object of embedded application state without a @type describes an item,
+// and returns its types.
+type EmbeddedJSONDetector func(obj map[string]interface{}) (types []string, ok bool)
+
+// DetectTypename is an EmbeddedJSONDetector for GraphQL objects, like the ones of an Apollo Client cache, which
+// uses their __typename as type.
+func DetectTypename(obj map[string]interface{}) ([]string, bool) {
+	if t, ok := obj["__typename"].(string); ok && t != "" {
+		return []string{t}, true
+	}
+	return nil, false
+}
+
+// WithEmbeddedJSON extracts items from the application state that single-page applications embed in documents:
+// <script id="__NEXT_DATA__">, window.__NUXT__, window.__APOLLO_STATE__ and other window.__*__ assignments and
+// <script type="application/json"> elements. Assignments of the state returned by a function called in place, like
+// the window.__NUXT__=(function(a,b){return {...}}(...)) of Nuxt 2, are read too. The outermost objects with a
+// @type, or matched by one of the given detectors, are added as items with their Origin set, e.g. to OriginNextData.
+func WithEmbeddedJSON(detectors ...EmbeddedJSONDetector) ParseOption {
+	return func(o *parseOptions) {
+		o.embeddedJSON = true
+		o.detectors = append(o.detectors, detectors...)
+	}
+}
+
+// appStateAssignment matches the assignment of application state to a global variable, e.g. "window.__NUXT__ =".
+var appStateAssignment = regexp.MustCompile(`(?:window\.)?(__[A-Z][A-Z0-9_]*__)\s*=\s*`)
+
+// readEmbeddedJSON adds the items found in the application state of the given script element.
+func (p *parser) readEmbeddedJSON(node *html.Node) {
+	text := unwrapScript(scriptText(node))
+	if text == "" {
+		return
+	}
+
+	typ, _ := getAttr("type", node)
+	mediaType, _, _ := mime.ParseMediaType(strings.TrimSpace(typ))
+	if mediaType == "application/json" {
+		origin := OriginJSON
+		if id, _ := getAttr("id", node); id == "__NEXT_DATA__" {
+			origin = OriginNextData
+		}
+		p.readEmbeddedState(text, origin)
+		return
+	}
+	if mediaType != "" && mediaType != "text/javascript" && mediaType != "application/javascript" {
+		return
+	}
+
+	for _, m := range appStateAssignment.FindAllStringSubmatchIndex(text, -1) {
+		origin := OriginAppState
+		switch text[m[2]:m[3]] {
+		case "__NUXT__":
+			origin = OriginNuxt
+		case "__APOLLO_STATE__":
+			origin = OriginApollo
+		}
+		value := jsonPrefix(text[m[1]:])
+		if value == "" {
+			value = iifePrefix(text[m[1]:])
+		}
+		if value != "" {
+			p.readEmbeddedState(value, origin)
+		}
+	}
+}
+
+// readEmbeddedState parses the JSON state and adds the items found in it.
+func (p *parser) readEmbeddedState(text, origin string) {
+	var state interface{}
+	if err := fixjson.Unmarshal([]byte(text), &state); err != nil {
+		log.Println("Error parsing json:", err)
+		return
+	}
+	p.findEmbeddedItems(state, origin)
+}
+
+// findEmbeddedItems adds the outermost objects of the value that have a @type or are matched by a detector.
+func (p *parser) findEmbeddedItems(value interface{}, origin string) {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, e := range v {
+			p.findEmbeddedItems(e, origin)
+		}
+	case map[string]interface{}:
+		if v["@type"] != nil {
+			item := NewItem()
+			item.Origin = origin
+			p.readJsonItem(item, v)
+			p.data.addItem(item)
+			return
+		}
+
+		for _, detect := range p.opts.detectors {
+			if types, ok := detect(v); ok {
+				item := NewItem()
+				item.Origin = origin
+				for _, t := range types {
+					item.addType(t)
+				}
+				p.readJsonItem(item, v)
+				p.data.addItem(item)
+				return
+			}
+		}
+
+		// Keys are sorted for the items to be found in a stable order.
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p.findEmbeddedItems(v[k], origin)
+		}
+	}
+}
+
+// jsonPrefix returns the JSON object or array the string starts with, or "" if it starts with something else.
+func jsonPrefix(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" || (s[0] != '{' && s[0] != '[') {
+		return ""
+	}
+
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			switch c {
+			case '\\':
+				i++
+			case quote:
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '"', '\'':
+			quote = c
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return s[:i+1]
+			}
+		}
+	}
+	return ""
+}
+
+// iifePrefix returns the state the string starts with as JSON when the state is returned by a function called in
+// place, like the window.__NUXT__ of Nuxt 2: (function(a,b){return {title:a,tags:[b]}}("Shoe","new")). The
+// parameters are replaced with the arguments and the object literals are converted to JSON, see jsToJSON. It
+// returns "" if the string starts with something else.
+func iifePrefix(s string) string {
+	s, ok := trimTokens(s, "(", "function", "(")
+	if !ok {
+		return ""
+	}
+	end := strings.IndexByte(s, ')')
+	if end < 0 {
+		return ""
+	}
+	var params []string
+	if list := strings.TrimSpace(s[:end]); list != "" {
+		params = strings.Split(list, ",")
+	}
+
+	s, ok = trimTokens(s[end+1:], "{", "return")
+	if !ok {
+		return ""
+	}
+	s = strings.TrimSpace(s)
+	body := jsonPrefix(s)
+	if body == "" {
+		return ""
+	}
+	s, ok = trimTokens(strings.TrimPrefix(strings.TrimSpace(s[len(body):]), ";"), "}")
+	if !ok {
+		return ""
+	}
+	s, ok = trimTokens(strings.TrimPrefix(strings.TrimSpace(s), ")"), "(")
+	if !ok {
+		return ""
+	}
+	args, ok := splitArgs(s)
+	if !ok {
+		return ""
+	}
+
+	vars := make(map[string]string, len(params))
+	for i, param := range params {
+		vars[strings.TrimSpace(param)] = "null"
+		if i < len(args) {
+			vars[strings.TrimSpace(param)] = jsToJSON(args[i], nil)
+		}
+	}
+	return jsToJSON(body, vars)
+}
+
+// trimTokens returns the string without the given tokens it starts with, in order and separated by whitespace, or
+// false if it doesn't start with them.
+func trimTokens(s string, tokens ...string) (string, bool) {
+	for _, token := range tokens {
+		s = strings.TrimSpace(s)
+		if !strings.HasPrefix(s, token) {
+			return s, false
+		}
+		s = s[len(token):]
+	}
+	return s, true
+}
+
+// splitArgs returns the arguments of a call, the string being what follows its opening parenthesis, or false if it
+// has no closing one.
+func splitArgs(s string) ([]string, bool) {
+	var args []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			switch c {
+			case '\\':
+				i++
+			case quote:
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '"', '\'':
+			quote = c
+		case '{', '[', '(':
+			depth++
+		case '}', ']':
+			depth--
+		case ')':
+			if depth == 0 {
+				if arg := strings.TrimSpace(s[start:i]); arg != "" || len(args) > 0 {
+					args = append(args, arg)
+				}
+				return args, true
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return nil, false
+}
+
+// jsToJSON converts the JavaScript literal to JSON: the names of object properties are quoted, strings are double
+// quoted, void 0 becomes null and !0 and !1 true and false, and the identifiers are replaced with their value in
+// vars, null when they have none.
+func jsToJSON(s string, vars map[string]string) string {
+	var b strings.Builder
+	// last is the last byte written that isn't a space, 'v' for values.
+	var last byte
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\'':
+			j := i + 1
+			for ; j < len(s) && s[j] != c; j++ {
+				if s[j] == '\\' {
+					j++
+				}
+			}
+			j = min(j+1, len(s))
+			if c == '"' {
+				b.WriteString(s[i:j])
+			} else {
+				b.WriteString(doubleQuote(s[i+1 : max(j-1, i+1)]))
+			}
+			i, last = j, 'v'
+		case c >= '0' && c <= '9':
+			j := i + 1
+			for ; j < len(s) && (isJSIdentPart(s[j]) || s[j] == '.' ||
+				(s[j] == '+' || s[j] == '-') && (s[j-1] == 'e' || s[j-1] == 'E')); j++ {
+			}
+			b.WriteString(s[i:j])
+			i, last = j, 'v'
+		case isJSIdentStart(c):
+			j := i + 1
+			for j < len(s) && isJSIdentPart(s[j]) {
+				j++
+			}
+			name := s[i:j]
+			next := strings.TrimLeft(s[j:], " \t\r\n")
+			switch {
+			case strings.HasPrefix(next, ":") && (last == '{' || last == ','):
+				b.WriteString(strconv.Quote(name))
+			case name == "true" || name == "false" || name == "null":
+				b.WriteString(name)
+			case name == "void":
+				j = len(s) - len(next)
+				for j < len(s) && isJSIdentPart(s[j]) {
+					j++
+				}
+				b.WriteString("null")
+			default:
+				if v, ok := vars[name]; ok {
+					b.WriteString(v)
+				} else {
+					b.WriteString("null")
+				}
+			}
+			i, last = j, 'v'
+		case c == '!' && i+1 < len(s) && (s[i+1] == '0' || s[i+1] == '1'):
+			b.WriteString(strconv.FormatBool(s[i+1] == '0'))
+			i, last = i+2, 'v'
+		default:
+			b.WriteByte(c)
+			if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+				last = c
+			}
+			i++
+		}
+	}
+	return b.String()
+}
+
+// doubleQuote returns the content of a single-quoted JavaScript string as a double-quoted one.
+func doubleQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == '\'':
+			b.WriteByte('\'')
+			i++
+		case s[i] == '\\' && i+1 < len(s):
+			b.WriteString(s[i : i+2])
+			i++
+		case s[i] == '"':
+			b.WriteString(`\"`)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// isJSIdentStart returns true if the byte can start a JavaScript identifier.
+func isJSIdentStart(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// isJSIdentPart returns true if the byte can be part of a JavaScript identifier.
+func isJSIdentPart(c byte) bool {
+	return isJSIdentStart(c) || c >= '0' && c <= '9'
+}
@@ -0,0 +1,57 @@
+package microdata
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseEmbeddedJSON(t *testing.T) {
+	html := `<html><head>
+		<script id="__NEXT_DATA__" type="application/json">{"props":{"pageProps":{"product":{"@type":"Product","name":"Sneaker","offers":{"@type":"Offer","price":"59.00"}}}}}</script>
+		<script>window.__APOLLO_STATE__ = {"Product:1":{"__typename":"Product","name":"Boot"},"ROOT_QUERY":{"product":{"__ref":"Product:1"}}};
+			window.__INITIAL_STATE__={"page": {"@type": "WebPage", "name": "Shoes",}}; console.log("done");</script>
+		<script type="application/json">{"config":{"theme":"dark"}}</script>
+	</head></html>`
+
+	data, err := ParseHTML(strings.NewReader(html), "text/html; charset=utf-8", "https://shop.example.com/", WithEmbeddedJSON(DetectTypename))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(data.Items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(b)
+	expected := `[{"type":["Product"],"properties":{"name":["Sneaker"],"offers":[{"type":["Offer"],"properties":{"price":["59.00"]}}]},"origin":"next-data"},{"type":["Product"],"properties":{"__typename":["Product"],"name":["Boot"]},"origin":"apollo"},{"type":["WebPage"],"properties":{"name":["Shoes"]},"origin":"app-state"}]`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
+
+func TestParseEmbeddedJSONDisabled(t *testing.T) {
+	data := ParseData(`<script id="__NEXT_DATA__" type="application/json">{"product":{"@type":"Product"}}</script>`, t)
+	if len(data.Items) != 0 {
+		t.Errorf("Result should have been 0 items, but it was %d", len(data.Items))
+	}
+}
+
+func TestParseEmbeddedJSONNuxt2(t *testing.T) {
+	html := `<script>window.__NUXT__=(function(a,b,c,d){return {layout:"default",data:[{product:{"@type":"Product",name:a,sku:'s-1',offers:{"@type":"Offer",price:b,priceCurrency:"EUR",availability:d}},related:[a,c]}],serverRendered:!0,error:void 0}}("Sneaker",59.5,{name:"Boot"}));</script>`
+
+	data, err := ParseHTML(strings.NewReader(html), "text/html; charset=utf-8", "https://shop.example.com/", WithEmbeddedJSON())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(data.Items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(b)
+	expected := `[{"type":["Product"],"properties":{"name":["Sneaker"],"offers":[{"type":["Offer"],"properties":{"price":[59.5],"priceCurrency":["EUR"]}}],"sku":["s-1"]},"origin":"nuxt"}]`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
@@ -0,0 +1,243 @@
+package microdata
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
+)
+
+// EncodingSource tells how the character encoding of a document was determined.
+type EncodingSource string
+
+const (
+	// EncodingFromBOM means the document starts with a byte order mark.
+	EncodingFromBOM EncodingSource = "bom"
+	// EncodingFromOverride means the caller chose the encoding with WithEncoding.
+	EncodingFromOverride EncodingSource = "override"
+	// EncodingFromContentType means the charset parameter of the Content-Type header was used.
+	EncodingFromContentType EncodingSource = "content-type"
+	// EncodingFromMeta means a <meta charset> or <meta http-equiv="Content-Type"> element was found by the prescan.
+	EncodingFromMeta EncodingSource = "meta"
+	// EncodingFromDetection means the encoding was detected from the content, which is valid non-ASCII UTF-8.
+	EncodingFromDetection EncodingSource = "detected"
+	// EncodingFromDefault means nothing declared an encoding and the default windows-1252 was used.
+	EncodingFromDefault EncodingSource = "default"
+)
+
+// Encoding describes the character encoding a document was decoded with.
+type Encoding struct {
+	// Name is the WHATWG name of the encoding, e.g. "utf-8" or "shift_jis".
+	Name   string         `json:"name"`
+	Source EncodingSource `json:"source"`
+}
+
+// sniffSize is the number of bytes examined to determine the encoding of a document.
+const sniffSize = 1024
+
+var boms = []struct {
+	bom  []byte
+	name string
+}{
+	{[]byte{0xef, 0xbb, 0xbf}, "utf-8"},
+	{[]byte{0xfe, 0xff}, "utf-16be"},
+	{[]byte{0xff, 0xfe}, "utf-16le"},
+}
+
+// decodeDocument returns a reader converting the content of r to UTF-8 and the encoding it was decoded with. It
+// implements the WHATWG encoding sniffing algorithm: a byte order mark, the override label, the charset parameter
+// of the contentType, a prescan of the first 1024 bytes for a <meta> declaration, UTF-8 detection and finally the
+// windows-1252 default, in that order.
+func decodeDocument(r io.Reader, contentType, override string) (io.Reader, *Encoding, error) {
+	prefix := make([]byte, sniffSize)
+	n, err := io.ReadFull(r, prefix)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, nil, err
+	}
+	prefix = prefix[:n]
+
+	e, enc, err := sniffEncoding(prefix, contentType, override)
+	if err != nil {
+		return nil, nil, err
+	}
+	if enc.Source == EncodingFromBOM {
+		for _, b := range boms {
+			if bytes.HasPrefix(prefix, b.bom) {
+				prefix = prefix[len(b.bom):]
+				break
+			}
+		}
+	}
+
+	return transform.NewReader(io.MultiReader(bytes.NewReader(prefix), r), e.NewDecoder()), enc, nil
+}
+
+// sniffEncoding determines the encoding of the document starting with the given prefix.
+func sniffEncoding(prefix []byte, contentType, override string) (encoding.Encoding, *Encoding, error) {
+	for _, b := range boms {
+		if bytes.HasPrefix(prefix, b.bom) {
+			e, name := charset.Lookup(b.name)
+			return e, &Encoding{Name: name, Source: EncodingFromBOM}, nil
+		}
+	}
+
+	if override != "" {
+		e, name := charset.Lookup(override)
+		if e == nil {
+			return nil, nil, fmt.Errorf("microdata: unsupported encoding %q", override)
+		}
+		return e, &Encoding{Name: name, Source: EncodingFromOverride}, nil
+	}
+
+	if e, name := charset.Lookup(contentTypeCharset(contentType)); e != nil {
+		return e, &Encoding{Name: name, Source: EncodingFromContentType}, nil
+	}
+
+	if e, name := prescanEncoding(prefix); e != nil {
+		return e, &Encoding{Name: name, Source: EncodingFromMeta}, nil
+	}
+
+	if isUTF8(prefix) {
+		e, name := charset.Lookup("utf-8")
+		return e, &Encoding{Name: name, Source: EncodingFromDetection}, nil
+	}
+
+	e, name := charset.Lookup("windows-1252")
+	return e, &Encoding{Name: name, Source: EncodingFromDefault}, nil
+}
+
+// contentTypeCharset returns the charset parameter of the given Content-Type. Malformed values like "charset=utf-8"
+// without a media type are accepted too.
+func contentTypeCharset(contentType string) string {
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		return params["charset"]
+	}
+	return charsetFromMetaContent(contentType)
+}
+
+// prescanEncoding looks for a <meta charset> or <meta http-equiv="Content-Type" content="..."> declaration in the
+// given bytes, following the WHATWG "prescan a byte stream to determine its encoding" algorithm.
+func prescanEncoding(content []byte) (encoding.Encoding, string) {
+	z := html.NewTokenizer(bytes.NewReader(content))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return nil, ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tagName, hasAttr := z.TagName()
+			if string(tagName) != "meta" {
+				continue
+			}
+
+			seen := make(map[string]bool)
+			gotPragma, needPragma, hasCharset := false, false, false
+			label := ""
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if seen[string(key)] {
+					continue
+				}
+				seen[string(key)] = true
+
+				switch string(key) {
+				case "http-equiv":
+					gotPragma = strings.EqualFold(string(val), "content-type")
+				case "content":
+					if !hasCharset {
+						if l := charsetFromMetaContent(string(val)); l != "" {
+							label, needPragma, hasCharset = l, true, true
+						}
+					}
+				case "charset":
+					label, needPragma, hasCharset = string(val), false, true
+				}
+			}
+
+			if !hasCharset || (needPragma && !gotPragma) {
+				continue
+			}
+
+			e, name := charset.Lookup(label)
+			if e == nil {
+				continue
+			}
+			// A document declaring UTF-16 while being readable as ASCII is UTF-8.
+			switch name {
+			case "utf-16be", "utf-16le":
+				e, name = charset.Lookup("utf-8")
+			case "x-user-defined":
+				e, name = charset.Lookup("windows-1252")
+			}
+			return e, name
+		}
+	}
+}
+
+// charsetFromMetaContent extracts the character encoding label from the content attribute of a meta element,
+// following the WHATWG "algorithm for extracting a character encoding from a meta element".
+func charsetFromMetaContent(s string) string {
+	for {
+		i := indexFold(s, "charset")
+		if i < 0 {
+			return ""
+		}
+		s = strings.TrimLeft(s[i+len("charset"):], " \t\n\f\r")
+		if !strings.HasPrefix(s, "=") {
+			continue
+		}
+		s = strings.TrimLeft(s[1:], " \t\n\f\r")
+		if s == "" {
+			return ""
+		}
+
+		if q := s[0]; q == '"' || q == '\'' {
+			s = s[1:]
+			end := strings.IndexByte(s, q)
+			if end < 0 {
+				return ""
+			}
+			return s[:end]
+		}
+		if end := strings.IndexAny(s, " \t\n\f\r;"); end >= 0 {
+			return s[:end]
+		}
+		return s
+	}
+}
+
+// indexFold returns the index of the first ASCII case-insensitive occurrence of substr in s, or -1.
+func indexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// isUTF8 returns true if the given bytes hold non-ASCII characters and are valid UTF-8, ignoring a character that
+// is cut off at the end.
+func isUTF8(b []byte) bool {
+	ascii := true
+	for len(b) > 0 {
+		if b[0] < utf8.RuneSelf {
+			b = b[1:]
+			continue
+		}
+		ascii = false
+		r, size := utf8.DecodeRune(b)
+		if r == utf8.RuneError && size == 1 {
+			return !utf8.FullRune(b) && len(b) < utf8.UTFMax
+		}
+		b = b[size:]
+	}
+	return !ascii
+}
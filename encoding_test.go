@@ -0,0 +1,76 @@
+package microdata
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+const encodingSnippet = `<div itemscope itemtype="https://schema.org/Thing"><span itemprop="name">%s</span></div>`
+
+func TestParseHTMLEncoding(t *testing.T) {
+	// "日本語" in Shift_JIS and "Москва" in windows-1251.
+	shiftJIS := "\x93\xfa\x96\x7b\x8c\xea"
+	cp1251 := "\xcc\xee\xf1\xea\xe2\xe0"
+
+	var testTable = []struct {
+		name        string
+		document    string
+		contentType string
+		opts        []ParseOption
+		expected    string
+		encoding    Encoding
+	}{
+		{"bom", "\xef\xbb\xbf" + strings.Replace(encodingSnippet, "%s", "日本語", 1), "text/html; charset=shift_jis", nil,
+			"日本語", Encoding{"utf-8", EncodingFromBOM}},
+		{"content-type", strings.Replace(encodingSnippet, "%s", cp1251, 1), "text/html; charset=windows-1251", nil,
+			"Москва", Encoding{"windows-1251", EncodingFromContentType}},
+		{"meta charset", `<meta charset="Shift_JIS">` + strings.Replace(encodingSnippet, "%s", shiftJIS, 1), "", nil,
+			"日本語", Encoding{"shift_jis", EncodingFromMeta}},
+		{"meta http-equiv", `<meta http-equiv="Content-Type" content="text/html; charset=windows-1251">` +
+			strings.Replace(encodingSnippet, "%s", cp1251, 1), "text/html", nil,
+			"Москва", Encoding{"windows-1251", EncodingFromMeta}},
+		{"meta after 512 bytes", "<!--" + strings.Repeat(" ", 600) + `--><meta charset="shift_jis">` +
+			strings.Replace(encodingSnippet, "%s", shiftJIS, 1), "", nil,
+			"日本語", Encoding{"shift_jis", EncodingFromMeta}},
+		{"detected", strings.Replace(encodingSnippet, "%s", "Москва", 1), "", nil,
+			"Москва", Encoding{"utf-8", EncodingFromDetection}},
+		{"override", `<meta charset="utf-8">` + strings.Replace(encodingSnippet, "%s", cp1251, 1), "text/html; charset=utf-8",
+			[]ParseOption{WithEncoding("cp1251")}, "Москва", Encoding{"windows-1251", EncodingFromOverride}},
+		{"default", strings.Replace(encodingSnippet, "%s", "caf\xe9", 1), "", nil,
+			"café", Encoding{"windows-1252", EncodingFromDefault}},
+	}
+
+	for _, test := range testTable {
+		// OneByteReader makes sure short reads are handled.
+		r := iotest.OneByteReader(strings.NewReader(test.document))
+		data, err := ParseHTML(r, test.contentType, "https://example.com", test.opts...)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if result := data.Items[0].Properties["name"][0].(string); result != test.expected {
+			t.Errorf("%s: Result should have been \"%s\", but it was \"%s\"", test.name, test.expected, result)
+		}
+		if *data.Encoding != test.encoding {
+			t.Errorf("%s: Encoding should have been %+v, but it was %+v", test.name, test.encoding, *data.Encoding)
+		}
+	}
+}
+
+func TestParseHTMLEmpty(t *testing.T) {
+	data, err := ParseHTML(bytes.NewReader(nil), "", "https://example.com")
+	if err != nil {
+		t.Fatalf("Result should have been no error, but it was \"%v\"", err)
+	}
+	if len(data.Items) != 0 {
+		t.Errorf("Result should have been 0 items, but it was %d", len(data.Items))
+	}
+}
+
+func TestParseHTMLUnknownEncoding(t *testing.T) {
+	if _, err := ParseHTML(strings.NewReader("<p>"), "", "https://example.com", WithEncoding("klingon")); err == nil {
+		t.Error("Result should have been an error for an unknown encoding")
+	}
+}
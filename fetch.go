@@ -0,0 +1,352 @@
+package microdata
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// DefaultUserAgent is the User-Agent header sent by a Fetcher unless the caller overrides it.
+const DefaultUserAgent = "microdata/1.0 (+https://github.com/findyourpaths/microdata)"
+
+// DefaultMaxBodySize is the maximum number of response body bytes a Fetcher reads unless the caller overrides it.
+const DefaultMaxBodySize = 10 << 20
+
+// DefaultTimeout is the timeout of the http.Client used by a Fetcher when the caller does not supply one.
+const DefaultTimeout = 30 * time.Second
+
+// ErrBodyTooLarge is returned when a response body is larger than the fetcher's maximum body size.
+var ErrBodyTooLarge = errors.New("microdata: response body too large")
+
+// ErrUnexpectedStatus is returned when a response status code is outside the fetcher's accepted range.
+var ErrUnexpectedStatus = errors.New("microdata: unexpected status code")
+
+// ErrNotHTML is returned when a response is not an HTML document, e.g. a PDF, an image or an archive.
+var ErrNotHTML = errors.New("microdata: not an HTML document")
+
+var defaultClient = &http.Client{Timeout: DefaultTimeout}
+
+// Fetcher retrieves HTML documents over HTTP and parses their microdata.
+// The zero value is not ready for use, create one with NewFetcher.
+type Fetcher struct {
+	// Client is the HTTP client used for requests.
+	Client *http.Client
+	// Header is added to every request.
+	Header http.Header
+	// MaxBodySize is the maximum number of body bytes read from a response. A value <= 0 disables the limit.
+	MaxBodySize int64
+	// MinStatus and MaxStatus define the inclusive range of accepted response status codes.
+	MinStatus int
+	MaxStatus int
+	// Cache, when set, stores fetched documents on disk and revalidates them on later requests.
+	Cache *Cache
+	// ParseOptions are applied when parsing the fetched documents.
+	ParseOptions []ParseOption
+}
+
+// FetchOption configures a Fetcher.
+type FetchOption func(*Fetcher)
+
+// WithClient sets the HTTP client used to fetch documents.
+func WithClient(client *http.Client) FetchOption {
+	return func(f *Fetcher) {
+		f.Client = client
+	}
+}
+
+// WithHeader sets a header sent with every request, replacing any existing value.
+func WithHeader(key, value string) FetchOption {
+	return func(f *Fetcher) {
+		f.Header.Set(key, value)
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) FetchOption {
+	return WithHeader("User-Agent", userAgent)
+}
+
+// WithMaxBodySize sets the maximum number of body bytes read from a response. A value <= 0 disables the limit.
+func WithMaxBodySize(n int64) FetchOption {
+	return func(f *Fetcher) {
+		f.MaxBodySize = n
+	}
+}
+
+// WithStatusRange sets the inclusive range of accepted response status codes.
+func WithStatusRange(min, max int) FetchOption {
+	return func(f *Fetcher) {
+		f.MinStatus = min
+		f.MaxStatus = max
+	}
+}
+
+// WithCache sets the cache used to store and revalidate fetched documents.
+func WithCache(cache *Cache) FetchOption {
+	return func(f *Fetcher) {
+		f.Cache = cache
+	}
+}
+
+// WithParseOptions sets the options applied when parsing the fetched documents.
+func WithParseOptions(opts ...ParseOption) FetchOption {
+	return func(f *Fetcher) {
+		f.ParseOptions = append(f.ParseOptions, opts...)
+	}
+}
+
+// NewFetcher returns a Fetcher with the given options applied on top of the defaults.
+func NewFetcher(opts ...FetchOption) *Fetcher {
+	f := &Fetcher{
+		Client:      defaultClient,
+		Header:      http.Header{"User-Agent": {DefaultUserAgent}},
+		MaxBodySize: DefaultMaxBodySize,
+		MinStatus:   200,
+		MaxStatus:   299,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Redirect is a hop in the redirect chain followed while fetching a document.
+type Redirect struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"statusCode"`
+}
+
+// Response describes a fetched document and holds its microdata.
+type Response struct {
+	// URL is the final URL of the document, after following redirects.
+	URL         string
+	StatusCode  int
+	Header      http.Header
+	ContentType string
+	// Redirects lists the URLs that redirected to the final URL, in the order they were requested.
+	Redirects []Redirect
+	// Cached is true when the document was served from the fetcher's cache.
+	Cached    bool
+	Microdata *Microdata
+}
+
+// FetchError is returned by a Fetcher when a document cannot be fetched or parsed. The wrapped error tells the
+// failures apart: transport errors (including context errors), ErrUnexpectedStatus, ErrNotHTML and ErrBodyTooLarge.
+type FetchError struct {
+	// URL is the final URL requested, after following redirects.
+	URL string
+	// StatusCode is 0 when no response was received.
+	StatusCode  int
+	Redirects   []Redirect
+	ContentType string
+	Err         error
+}
+
+func (e *FetchError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("microdata: fetching %s (status %d): %v", e.URL, e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("microdata: fetching %s: %v", e.URL, e.Err)
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// Fetch fetches the HTML document at the given URL and returns the response with its microdata.
+// All errors are of type *FetchError.
+func (f *Fetcher) Fetch(ctx context.Context, urlStr string) (*Response, error) {
+	resp, body, err := f.open(ctx, urlStr)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	resp.Microdata, err = ParseHTML(body, resp.ContentType, resp.URL, f.ParseOptions...)
+	if err != nil {
+		return nil, resp.error(err)
+	}
+	return resp, nil
+}
+
+// ParseURL fetches the HTML document at the given URL and returns the microdata.
+func (f *Fetcher) ParseURL(ctx context.Context, urlStr string) (*Microdata, error) {
+	resp, err := f.Fetch(ctx, urlStr)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Microdata, nil
+}
+
+// open sends the request for the given URL and returns the response metadata and the checked, size limited body.
+// The caller must close the body.
+func (f *Fetcher) open(ctx context.Context, urlStr string) (*Response, io.ReadCloser, error) {
+	resp, body, err := f.get(ctx, urlStr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, err := checkHTML(body, resp.ContentType)
+	if err != nil {
+		body.Close()
+		return nil, nil, resp.error(err)
+	}
+	return resp, readCloser{r, body}, nil
+}
+
+// get sends the request for the given URL and returns the response metadata and the size limited body of any
+// document with an accepted status code. When the fetcher has a cache, stored documents are revalidated or, in
+// offline mode, served without a request. The caller must close the body.
+func (f *Fetcher) get(ctx context.Context, urlStr string) (*Response, io.ReadCloser, error) {
+	var entry *cacheEntry
+	if f.Cache != nil {
+		entry = f.Cache.load(urlStr)
+		if f.Cache.Offline {
+			if entry == nil {
+				return nil, nil, &FetchError{URL: urlStr, Err: ErrNotCached}
+			}
+			return f.Cache.open(urlStr, entry)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlStr, nil)
+	if err != nil {
+		return nil, nil, &FetchError{URL: urlStr, Err: err}
+	}
+	for key, values := range f.Header {
+		req.Header[key] = values
+	}
+	if entry != nil {
+		entry.addValidators(req.Header)
+	}
+
+	client := f.Client
+	if client == nil {
+		client = defaultClient
+	}
+
+	httpResp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, &FetchError{URL: urlStr, Err: err}
+	}
+
+	resp := &Response{
+		URL:         httpResp.Request.URL.String(),
+		StatusCode:  httpResp.StatusCode,
+		Header:      httpResp.Header,
+		ContentType: httpResp.Header.Get("Content-Type"),
+		Redirects:   redirectChain(httpResp.Request),
+	}
+
+	if entry != nil && resp.StatusCode == http.StatusNotModified {
+		httpResp.Body.Close()
+		return f.Cache.open(urlStr, entry)
+	}
+
+	if resp.StatusCode < f.MinStatus || resp.StatusCode > f.MaxStatus {
+		httpResp.Body.Close()
+		return nil, nil, resp.error(ErrUnexpectedStatus)
+	}
+
+	var body io.Reader = httpResp.Body
+	if f.MaxBodySize > 0 {
+		body = &limitedReader{r: httpResp.Body, n: f.MaxBodySize}
+	}
+
+	if f.Cache != nil && resp.StatusCode == http.StatusOK {
+		defer httpResp.Body.Close()
+		b, err := io.ReadAll(body)
+		if err != nil {
+			return nil, nil, resp.error(err)
+		}
+		if err := f.Cache.store(urlStr, resp, b); err != nil {
+			log.Println("Error writing cache:", err)
+		}
+		return resp, io.NopCloser(bytes.NewReader(b)), nil
+	}
+
+	return resp, readCloser{body, httpResp.Body}, nil
+}
+
+// error returns a FetchError for the response wrapping the given error.
+func (r *Response) error(err error) *FetchError {
+	return &FetchError{
+		URL:         r.URL,
+		StatusCode:  r.StatusCode,
+		Redirects:   r.Redirects,
+		ContentType: r.ContentType,
+		Err:         err,
+	}
+}
+
+// redirectChain returns the redirects that led to the given request, oldest first.
+func redirectChain(req *http.Request) []Redirect {
+	var chain []Redirect
+	for req.Response != nil {
+		resp := req.Response
+		req = resp.Request
+		chain = append([]Redirect{{URL: req.URL.String(), StatusCode: resp.StatusCode}}, chain...)
+	}
+	return chain
+}
+
+// checkHTML returns an error wrapping ErrNotHTML when the given content type, or the sniffed content type if it is
+// absent or generic, is not an HTML document. The returned reader replays the sniffed bytes.
+func checkHTML(r io.Reader, contentType string) (io.Reader, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/html", "application/xhtml+xml":
+		return r, nil
+	case "", "application/octet-stream":
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrNotHTML, mediaType)
+	}
+
+	b := make([]byte, 512)
+	n, err := io.ReadFull(r, b)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	b = b[:n]
+
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(b))
+	if sniffed != "text/html" && sniffed != "text/plain" {
+		return nil, fmt.Errorf("%w: %s", ErrNotHTML, sniffed)
+	}
+	return io.MultiReader(bytes.NewReader(b), r), nil
+}
+
+// readCloser combines a reader with the closer of the underlying stream.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// limitedReader reads from r until n bytes are consumed and returns ErrBodyTooLarge if more data follows.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		var b [1]byte
+		if n, _ := l.r.Read(b[:]); n > 0 {
+			return 0, ErrBodyTooLarge
+		}
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
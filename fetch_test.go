@@ -0,0 +1,177 @@
+package microdata
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseURLContextHeaders(t *testing.T) {
+	html := `
+		<div itemscope itemtype="https://example.com/Person">
+			<p>My name is <span itemprop="name">Penelope</span>.</p>
+		</div>`
+
+	var userAgent, accept string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		accept = r.Header.Get("Accept")
+		_, _ = w.Write([]byte(html))
+	}))
+	defer ts.Close()
+
+	data, err := ParseURLContext(context.Background(), ts.URL, WithHeader("Accept", "text/html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result := data.Items[0].Properties["name"][0].(string); result != "Penelope" {
+		t.Errorf("Result should have been \"Penelope\", but it was \"%s\"", result)
+	}
+	if userAgent != DefaultUserAgent {
+		t.Errorf("User-Agent should have been \"%s\", but it was \"%s\"", DefaultUserAgent, userAgent)
+	}
+	if accept != "text/html" {
+		t.Errorf("Accept should have been \"text/html\", but it was \"%s\"", accept)
+	}
+}
+
+func TestParseURLContextStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<div itemscope itemtype="https://example.com/Error"></div>`))
+	}))
+	defer ts.Close()
+
+	if _, err := ParseURLContext(context.Background(), ts.URL); err == nil {
+		t.Error("Result should have been an error for a 404 response")
+	}
+
+	data, err := ParseURLContext(context.Background(), ts.URL, WithStatusRange(200, 404))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Items) != 1 {
+		t.Errorf("Result should have been 1 item, but it was %d", len(data.Items))
+	}
+}
+
+func TestParseURLContextMaxBodySize(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html><body>" + strings.Repeat("a", 4096) + "</body></html>"))
+	}))
+	defer ts.Close()
+
+	_, err := ParseURLContext(context.Background(), ts.URL, WithMaxBodySize(1024))
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("Result should have been \"%v\", but it was \"%v\"", ErrBodyTooLarge, err)
+	}
+}
+
+func TestParseURLContextCanceled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := ParseURLContext(ctx, ts.URL); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Result should have been \"%v\", but it was \"%v\"", context.DeadlineExceeded, err)
+	}
+}
+
+func TestFetchRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusFound)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<div itemscope itemtype="https://example.com/Page"></div>`))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	resp, err := NewFetcher().Fetch(context.Background(), ts.URL+"/old")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.URL != ts.URL+"/new" {
+		t.Errorf("URL should have been \"%s\", but it was \"%s\"", ts.URL+"/new", resp.URL)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("StatusCode should have been %d, but it was %d", http.StatusOK, resp.StatusCode)
+	}
+	expected := []Redirect{{ts.URL + "/old", http.StatusMovedPermanently}, {ts.URL + "/moved", http.StatusFound}}
+	if len(resp.Redirects) != len(expected) || resp.Redirects[0] != expected[0] || resp.Redirects[1] != expected[1] {
+		t.Errorf("Redirects should have been %v, but it was %v", expected, resp.Redirects)
+	}
+	if len(resp.Microdata.Items) != 1 {
+		t.Errorf("Result should have been 1 item, but it was %d", len(resp.Microdata.Items))
+	}
+}
+
+func TestFetchError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+	mux.HandleFunc("/report.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		_, _ = w.Write([]byte("%PDF-1.4"))
+	})
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write([]byte("PK\x03\x04zipdata"))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	var testTable = []struct {
+		path        string
+		statusCode  int
+		contentType string
+		expected    error
+	}{
+		{"/missing", http.StatusNotFound, "text/plain; charset=utf-8", ErrUnexpectedStatus},
+		{"/report.pdf", http.StatusOK, "application/pdf", ErrNotHTML},
+		{"/download", http.StatusOK, "application/octet-stream", ErrNotHTML},
+	}
+
+	for _, test := range testTable {
+		_, err := NewFetcher().Fetch(context.Background(), ts.URL+test.path)
+
+		var fetchErr *FetchError
+		if !errors.As(err, &fetchErr) {
+			t.Fatalf("Result should have been a *FetchError, but it was \"%v\"", err)
+		}
+		if !errors.Is(err, test.expected) {
+			t.Errorf("Result should have been \"%v\", but it was \"%v\"", test.expected, err)
+		}
+		if fetchErr.StatusCode != test.statusCode {
+			t.Errorf("StatusCode should have been %d, but it was %d", test.statusCode, fetchErr.StatusCode)
+		}
+		if fetchErr.ContentType != test.contentType {
+			t.Errorf("ContentType should have been \"%s\", but it was \"%s\"", test.contentType, fetchErr.ContentType)
+		}
+	}
+
+	_, err := NewFetcher().Fetch(context.Background(), "http://127.0.0.1:1/unreachable")
+	var fetchErr *FetchError
+	if !errors.As(err, &fetchErr) || fetchErr.StatusCode != 0 {
+		t.Errorf("Result should have been a *FetchError without status, but it was \"%v\"", err)
+	}
+}
@@ -0,0 +1,217 @@
+package microdata
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WithFlattening flattens the items of documents once parsed, see Microdata.Flatten.
+func WithFlattening() ParseOption {
+	return func(o *parseOptions) {
+		o.flatten = true
+	}
+}
+
+// Flatten builds the node map of the items: the items that share an ID are merged into the first one, whether
+// they come from different JSON-LD scripts, @graph arrays or microdata itemids, and the references to a node, the
+// items that have its ID only, like {"@id": "#org"} in JSON-LD, are replaced with the merged item. IDs are
+// resolved against the base URL, and the "@id" property of items read from JSON-LD as is becomes their ID. Blank
+// node IDs, e.g. "_:b0", are local to their JSON-LD script: the items of a script that share one are merged, and the
+// references to it from the same script are replaced, like for other IDs.
+//
+// The items then form a graph in which the same item can be the value of several properties, and which can have
+// cycles, e.g. an organization nested in the founder it links to. Item.MarshalJSON, Item.CountPaths and the JSON-LD
+// methods stop at cycles. Top-level items and @graph arrays keep a single entry for each merged item.
+//
+// Flatten returns the merged items that have an ID, in document order.
+func (m *Microdata) Flatten() []*Item {
+	f := &flattener{
+		nodes:    make(map[string]*Item),
+		aliases:  make(map[*Item]*Item),
+		scripts:  make(map[*Item]int),
+		resolved: make(map[*Item]bool),
+	}
+	if base, err := url.Parse(m.BaseURL); err == nil && m.BaseURL != "" {
+		f.base = base
+	}
+
+	visited := make(map[*Item]bool)
+	for _, item := range m.Items {
+		f.collect(item, item.script, visited)
+	}
+	items := m.Items[:0]
+	seen := make(map[*Item]bool)
+	for _, item := range m.Items {
+		if resolved := f.resolve(item); !seen[resolved] {
+			seen[resolved] = true
+			items = append(items, resolved)
+		}
+	}
+	m.Items = items
+	return f.order
+}
+
+// flattener holds the node map of Microdata.Flatten.
+type flattener struct {
+	base *url.URL
+	// nodes maps the IDs to the merged items, in order.
+	nodes map[string]*Item
+	order []*Item
+	// aliases maps the items merged into another one to it.
+	aliases map[*Item]*Item
+	// scripts maps the items to the JSON-LD script they were read from, see Item.script.
+	scripts map[*Item]int
+	// resolved holds the items whose references are resolved or being resolved.
+	resolved map[*Item]bool
+}
+
+// collect adds the item and the items nested in it, read from the given script, to the node map.
+func (f *flattener) collect(item *Item, script int, visited map[*Item]bool) {
+	if visited[item] {
+		return
+	}
+	visited[item] = true
+	f.scripts[item] = script
+
+	if key := f.key(item); key != "" && !isReference(item) {
+		if node, ok := f.nodes[key]; ok {
+			mergeItem(node, item)
+			f.aliases[item] = node
+		} else {
+			f.nodes[key] = item
+			f.order = append(f.order, item)
+		}
+	}
+
+	for _, values := range item.Properties {
+		for _, v := range values {
+			if nested, ok := v.(*Item); ok {
+				f.collect(nested, script, visited)
+			}
+		}
+	}
+}
+
+// key returns the key of the item in the node map: its resolved ID, prefixed with the number of its script for
+// blank nodes, or "" if it has no ID. The "@id" property of items read from JSON-LD as is becomes their ID.
+func (f *flattener) key(item *Item) string {
+	if item.ID == "" {
+		if values := item.Properties["@id"]; len(values) > 0 {
+			if s, ok := values[0].(string); ok && s != "" {
+				item.ID = s
+				delete(item.Properties, "@id")
+			}
+		}
+	}
+	switch {
+	case item.ID == "":
+		return ""
+	case strings.HasPrefix(item.ID, "_:"):
+		return strconv.Itoa(f.scripts[item]) + " " + item.ID
+	}
+	if f.base != nil {
+		if u, err := f.base.Parse(item.ID); err == nil {
+			item.ID = u.String()
+		}
+	}
+	return item.ID
+}
+
+// resolve returns the item that replaces the given one: the item it was merged into or the one it references.
+func (f *flattener) resolve(item *Item) *Item {
+	target := item
+	if node, ok := f.aliases[item]; ok {
+		target = node
+	} else if node, ok := f.nodes[f.key(item)]; ok && item.ID != "" && isReference(item) {
+		target = node
+	}
+	if f.resolved[target] {
+		return target
+	}
+
+	f.resolved[target] = true
+	keys := make([]string, 0, len(target.Properties))
+	for key := range target.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		target.Properties[key] = f.resolveValues(target.Properties[key])
+	}
+	return target
+}
+
+// resolveValues resolves the items of the values, dropping the items that occur more than once.
+func (f *flattener) resolveValues(values []interface{}) []interface{} {
+	result := values[:0]
+	seen := make(map[*Item]bool)
+	for _, v := range values {
+		if nested, ok := v.(*Item); ok {
+			resolved := f.resolve(nested)
+			if seen[resolved] {
+				continue
+			}
+			seen[resolved] = true
+			v = resolved
+		}
+		result = append(result, v)
+	}
+	return result
+}
+
+// isReference returns true if the item has an ID only.
+func isReference(item *Item) bool {
+	if len(item.Types) > 0 {
+		return false
+	}
+	for key := range item.Properties {
+		if key != "@id" {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeItem adds the types and property values of src that dst doesn't have to dst.
+func mergeItem(dst, src *Item) {
+	for _, t := range src.Types {
+		if !containsString(dst.Types, t) {
+			dst.addType(t)
+		}
+	}
+	for key, values := range src.Properties {
+		for i, v := range values {
+			if containsValue(dst.Properties[key], v) {
+				continue
+			}
+			dst.addProperty(key, v)
+			if src.isURL(key, v) {
+				dst.addURL(key, v.(string))
+			}
+			if html := src.InnerHTML[key]; i < len(html) {
+				if dst.InnerHTML == nil {
+					dst.InnerHTML = make(map[string][]string)
+				}
+				dst.InnerHTML[key] = append(dst.InnerHTML[key], html[i])
+			}
+		}
+	}
+	if dst.Origin == "" {
+		dst.Origin = src.Origin
+	}
+}
+
+// containsValue returns true if the values contain the given string, number, boolean or item pointer.
+func containsValue(values []interface{}, v interface{}) bool {
+	switch v.(type) {
+	case string, float64, int, bool, *Item:
+		for _, e := range values {
+			if e == v {
+				return true
+			}
+		}
+	}
+	return false
+}
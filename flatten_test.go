@@ -0,0 +1,149 @@
+package microdata
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFlatten(t *testing.T) {
+	html := `<script type="application/ld+json">{
+		"@context": "https://schema.org",
+		"@graph": [
+			{"@type": "WebPage", "@id": "https://example.com/#webpage", "name": "Home",
+				"isPartOf": {"@id": "https://example.com/#website"}, "author": {"@id": "#jane"}},
+			{"@type": "WebSite", "@id": "https://example.com/#website", "publisher": {"@id": "#org"}},
+			{"@type": "Organization", "@id": "#org", "name": "Example", "founder": {"@id": "#jane"}}
+		]
+	}</script>
+	<script type="application/ld+json">{
+		"@context": "https://schema.org",
+		"@type": "Person",
+		"@id": "https://example.com/#jane",
+		"name": "Jane",
+		"worksFor": {"@id": "#org"}
+	}</script>
+	<script type="application/ld+json">{"@context": "https://schema.org", "@type": "Organization", "@id": "#org",
+		"url": "https://example.com"}</script>`
+
+	data, err := ParseHTML(strings.NewReader(html), "text/html; charset=utf-8", "https://example.com/",
+		WithFlattening())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(data.Items) != 3 {
+		t.Fatalf("Result should have been 3 items, but it was %d", len(data.Items))
+	}
+
+	page := data.GetFirstOfType("WebPage")
+	if page == nil {
+		t.Fatal("Result should have been a WebPage, but it was nil")
+	}
+	website, _ := page.GetNestedItem("isPartOf")
+	publisher, _ := website.GetNestedItem("publisher")
+	result, _ := publisher.GetProperty("url")
+	if expected := "https://example.com"; result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%v\"", expected, result)
+	}
+
+	org := data.GetFirstOfType("Organization")
+	if org != publisher {
+		t.Errorf("Result should have been the merged organization, but it was %v", org)
+	}
+
+	// The organization's founder is the author of the page, whose worksFor refers back to the organization.
+	author, _ := page.GetNestedItem("author")
+	if worksFor, _ := author.GetNestedItem("worksFor"); worksFor != org {
+		t.Errorf("Result should have been the merged organization, but it was %v", worksFor)
+	}
+	if founder, _ := org.GetNestedItem("founder"); founder != author {
+		t.Errorf("Result should have been the author, but it was %v", founder)
+	}
+
+	// The cycle is written as a reference.
+	b, err := json.Marshal(author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"type":["Person"],"properties":{"@context":["https://schema.org"],"name":["Jane"],"worksFor":[{"type":["Organization"],"properties":{"@context":["https://schema.org"],"founder":[{"type":[],"properties":{},"id":"https://example.com/#jane"}],"name":["Example"],"url":["https://example.com"]},"id":"https://example.com/#org"}]},"id":"https://example.com/#jane"}`
+	if string(b) != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, string(b))
+	}
+
+	paths := make(map[string]int)
+	author.CountPaths("", &paths)
+	if n := paths["worksFor.founder[*microdata.Item]"]; n != 1 || len(paths) != 7 {
+		t.Errorf("Result should have been 7 paths with 1 founder, but it was %v", paths)
+	}
+
+	if _, err := json.Marshal(data); err != nil {
+		t.Errorf("Result should have been marshaled, but it failed with %v", err)
+	}
+}
+
+func TestFlattenItemID(t *testing.T) {
+	html := `<div itemscope itemtype="https://schema.org/Organization" itemid="https://example.com/#org">
+		<span itemprop="name">Example</span>
+	</div>
+	<script type="application/ld+json">{
+		"@context": "https://schema.org",
+		"@type": "Article",
+		"publisher": {"@id": "https://example.com/#org"},
+		"author": {"@id": "_:b0"}
+	}</script>`
+
+	data := ParseData(html, t)
+	nodes := data.Flatten()
+	if len(nodes) != 1 {
+		t.Fatalf("Result should have been 1 node, but it was %d", len(nodes))
+	}
+
+	article := data.GetFirstOfType("Article")
+	publisher, _ := article.GetNestedItem("publisher")
+	if publisher != nodes[0] {
+		t.Errorf("Result should have been the microdata organization, but it was %v", publisher)
+	}
+	author, _ := article.GetNestedItem("author")
+	if author.ID != "_:b0" {
+		t.Errorf("Result should have been \"_:b0\", but it was \"%s\"", author.ID)
+	}
+}
+
+func TestFlattenBlankNodes(t *testing.T) {
+	html := `<script type="application/ld+json">{
+		"@context": "https://schema.org",
+		"@graph": [
+			{"@type": "Article", "headline": "First", "publisher": {"@id": "_:org"}},
+			{"@type": "Organization", "@id": "_:org", "name": "Example"}
+		]
+	}</script>
+	<script type="application/ld+json">[
+		{"@context": "https://schema.org", "@type": "Article", "headline": "Second", "publisher": {"@id": "_:org"}},
+		{"@context": "https://schema.org", "@type": "Organization", "@id": "_:org", "name": "Other"}
+	]</script>`
+
+	data, err := ParseHTML(strings.NewReader(html), "text/html; charset=utf-8", "https://example.com/",
+		WithFlattening())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, item := range data.Items {
+		if graph, ok := item.GetNested("@graph"); ok {
+			item = graph.Items[0]
+		}
+		if !item.IsOfType("Article") {
+			continue
+		}
+		publisher, _ := item.GetNestedItem("publisher")
+		name, _ := publisher.GetProperty("name")
+		names = append(names, fmt.Sprint(name))
+	}
+	result := strings.Join(names, " ")
+	if expected := "Example Other"; result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
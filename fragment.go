@@ -0,0 +1,106 @@
+package microdata
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Origins of the items found in embedded fragments.
+const (
+	// OriginNoscript marks items found in the content of a <noscript> element.
+	OriginNoscript = "noscript"
+	// OriginTemplate marks items found in the content of a <template> element.
+	OriginTemplate = "template"
+	// OriginShadowRoot marks items found in a declarative shadow root, a <template shadowrootmode> element.
+	OriginShadowRoot = "shadow-root"
+	// OriginIframeSrcdoc marks items found in the document of an <iframe srcdoc> attribute.
+	OriginIframeSrcdoc = "iframe-srcdoc"
+)
+
+// WithEmbeddedFragments extracts the microdata and JSON-LD of the markup that browsers don't render as part of the
+// document: the content of <noscript> elements, which is raw text when parsing with scripting enabled, the
+// documents of <iframe srcdoc> attributes and the content of <template> elements, including declarative shadow
+// roots. The items found there have their Origin set, e.g. to OriginNoscript.
+func WithEmbeddedFragments() ParseOption {
+	return func(o *parseOptions) {
+		o.fragments = true
+	}
+}
+
+// nodeOrigin returns the origin of the items found in the given node: OriginTemplate or OriginShadowRoot when it is
+// in a <template> element, "" otherwise or when fragments aren't extracted.
+func (p *parser) nodeOrigin(n *html.Node) string {
+	if !p.opts.fragments {
+		return ""
+	}
+	for ; n != nil; n = n.Parent {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Template {
+			if _, ok := getAttr("shadowrootmode", n); ok {
+				return OriginShadowRoot
+			}
+			return OriginTemplate
+		}
+	}
+	return ""
+}
+
+// readFragment parses the markup embedded in the given <noscript> or <iframe srcdoc> element and adds its items.
+func (p *parser) readFragment(n *html.Node) {
+	var root *html.Node
+	var origin string
+	switch n.DataAtom {
+	case atom.Noscript:
+		if n.FirstChild == nil || n.FirstChild.Type != html.TextNode {
+			// Parsed as markup already, when scripting is disabled.
+			return
+		}
+		nodes, err := html.ParseFragment(strings.NewReader(scriptText(n)), &html.Node{
+			Type:     html.ElementNode,
+			Data:     "body",
+			DataAtom: atom.Body,
+		})
+		if err != nil {
+			return
+		}
+		root = &html.Node{Type: html.DocumentNode}
+		for _, c := range nodes {
+			root.AppendChild(c)
+		}
+		origin = OriginNoscript
+	case atom.Iframe:
+		srcdoc, ok := getAttr("srcdoc", n)
+		if !ok {
+			return
+		}
+		doc, err := html.Parse(strings.NewReader(srcdoc))
+		if err != nil {
+			return
+		}
+		root, origin = doc, OriginIframeSrcdoc
+	default:
+		return
+	}
+
+	// Only the microdata, RDFa and JSON-LD items of the fragment are of interest, with its expanded JSON-LD when
+	// parsing WithJSONLDExpansion. Its base URL is the one of the embedding document.
+	opts := p.opts
+	opts.openGraphItem, opts.microformatItems, opts.embeddedJSON, opts.flatten = false, false, false, false
+	fp, _ := newParser(root, p.baseURL, opts)
+	data, err := fp.parse()
+	if err != nil {
+		return
+	}
+	for _, item := range data.Items {
+		if item.Origin == "" {
+			item.Origin = origin
+		}
+		if item.script != 0 {
+			item.script += p.data.scripts
+		}
+		p.data.addItem(item)
+	}
+	p.data.scripts += data.scripts
+	p.data.JSONLD = append(p.data.JSONLD, data.JSONLD...)
+}
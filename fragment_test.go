@@ -0,0 +1,70 @@
+package microdata
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const fragmentSnippet = `<html><head></head><body>
+	<div itemscope itemtype="https://schema.org/Thing"><span itemprop="name">Visible</span></div>
+	<noscript><img itemscope itemtype="https://schema.org/ImageObject" src="/lazy.jpg"><div itemscope itemtype="https://schema.org/Thing"><span itemprop="name">Noscript</span></div></noscript>
+	<template><div itemscope itemtype="https://schema.org/Thing"><span itemprop="name">Template</span></div></template>
+	<product-card><template shadowrootmode="open"><script type="application/ld+json">{"@type":"Product","name":"Shadow"}</script></template></product-card>
+	<iframe srcdoc="<div itemscope itemtype='https://schema.org/Thing'><a itemprop='url' href='page'>Srcdoc</a></div>"></iframe>
+</body></html>`
+
+func TestParseEmbeddedFragments(t *testing.T) {
+	data, err := ParseHTML(strings.NewReader(fragmentSnippet), "text/html; charset=utf-8", "https://example.com/dir/", WithEmbeddedFragments())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, item := range data.Items {
+		name, _ := item.GetProperty("name", "url")
+		b, _ := json.Marshal(name)
+		names = append(names, item.Origin+":"+string(b))
+	}
+	result := strings.Join(names, " ")
+	expected := `:"Visible" template:"Template" shadow-root:"Shadow" noscript:null noscript:"Noscript" iframe-srcdoc:"https://example.com/dir/page"`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
+
+func TestParseWithoutEmbeddedFragments(t *testing.T) {
+	data, err := ParseHTML(strings.NewReader(fragmentSnippet), "text/html; charset=utf-8", "https://example.com/dir/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Template content is part of the tree and always read, but not marked.
+	for _, item := range data.Items {
+		if item.Origin != "" {
+			t.Errorf("Result should have been no origin, but it was %q", item.Origin)
+		}
+	}
+	if len(data.Items) != 3 {
+		t.Errorf("Result should have been 3 items, but it was %d", len(data.Items))
+	}
+}
+
+func TestParseEmbeddedFragmentsJSONLDExpansion(t *testing.T) {
+	html := `<noscript><script type="application/ld+json">{"@context":"https://schema.org","@type":"Thing","name":"Noscript"}</script></noscript>`
+	data, err := ParseHTML(strings.NewReader(html), "text/html; charset=utf-8", "https://example.com/",
+		WithEmbeddedFragments(), WithJSONLDExpansion())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(data.JSONLD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(b)
+	expected := `[{"@type":["http://schema.org/Thing"],"http://schema.org/name":[{"@value":"Noscript"}]}]`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
@@ -5,6 +5,5 @@ go 1.21
 require (
 	github.com/astappiev/fixjson v1.0.1
 	golang.org/x/net v0.26.0
+	golang.org/x/text v0.16.0
 )
-
-require golang.org/x/text v0.16.0 // indirect
@@ -0,0 +1,415 @@
+package microdata
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+)
+
+// ErrDocumentNotFound is returned by DefaultDocumentLoader for the documents it doesn't embed.
+var ErrDocumentNotFound = errors.New("microdata: jsonld: document not found")
+
+// JSONLDError is returned when JSON-LD processing fails. Code is the error code of the JSON-LD 1.1 API, see
+// https://www.w3.org/TR/json-ld11-api/#jsonlderrorcode, e.g. "invalid @id value".
+type JSONLDError struct {
+	Code string
+	Err  error
+}
+
+func (e *JSONLDError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("microdata: jsonld: %s: %v", e.Code, e.Err)
+	}
+	return "microdata: jsonld: " + e.Code
+}
+
+func (e *JSONLDError) Unwrap() error {
+	return e.Err
+}
+
+// jsonldError returns a JSONLDError with the given code wrapping err.
+func jsonldError(code string, err error) error {
+	return &JSONLDError{Code: code, Err: err}
+}
+
+// DocumentLoader returns the parsed JSON of the document at the given URL, e.g. a remote context referenced by a
+// @context or @import entry.
+type DocumentLoader func(url string) (interface{}, error)
+
+// contexts holds the schema.org context published at https://schema.org/docs/jsonldcontext.jsonld, fetched into
+// contexts/schema.org.jsonld with go generate, and a subset of it, contexts/schema.org-subset.jsonld, used when
+// the published one wasn't fetched. The subset has the @vocab, the prefixes, the aliases of @id and @type and the
+// terms of the common properties whose values are IRIs or dates, e.g. url and datePublished; other terms expand to
+// the same IRIs, but their values aren't typed.
+//
+//go:generate curl -sSfL -o contexts/schema.org.jsonld https://schema.org/docs/jsonldcontext.jsonld
+//go:embed contexts
+var contexts embed.FS
+
+// schemaOrgContext is the embedded schema.org context, see contexts.
+var schemaOrgContext = func() []byte {
+	if b, err := contexts.ReadFile("contexts/schema.org.jsonld"); err == nil {
+		return b
+	}
+	b, _ := contexts.ReadFile("contexts/schema.org-subset.jsonld")
+	return b
+}()
+
+// schemaOrgContextURLs are the URLs the schema.org context is referenced with.
+var schemaOrgContextURLs = map[string]bool{
+	"http://schema.org":                            true,
+	"http://schema.org/":                           true,
+	"https://schema.org":                           true,
+	"https://schema.org/":                          true,
+	"http://schema.org/docs/jsonldcontext.json":    true,
+	"http://schema.org/docs/jsonldcontext.jsonld":  true,
+	"https://schema.org/docs/jsonldcontext.json":   true,
+	"https://schema.org/docs/jsonldcontext.jsonld": true,
+	"http://www.schema.org":                        true,
+	"http://www.schema.org/":                       true,
+	"https://www.schema.org":                       true,
+	"https://www.schema.org/":                      true,
+}
+
+// DefaultDocumentLoader is the DocumentLoader used unless another one is given. It doesn't access the network: it
+// serves the schema.org context embedded in the package for its URLs, e.g. "https://schema.org", and returns
+// ErrDocumentNotFound for other URLs.
+func DefaultDocumentLoader(urlStr string) (interface{}, error) {
+	if !schemaOrgContextURLs[urlStr] {
+		return nil, ErrDocumentNotFound
+	}
+	var doc interface{}
+	if err := json.Unmarshal(schemaOrgContext, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// JSONLDOption configures the JSON-LD processing algorithms.
+type JSONLDOption func(*jsonldOptions)
+
+type jsonldOptions struct {
+	base          string
+	loader        DocumentLoader
+	expandContext interface{}
+}
+
+// WithBaseIRI sets the IRI relative IRIs are resolved against, the document URL when parsing documents.
+func WithBaseIRI(iri string) JSONLDOption {
+	return func(o *jsonldOptions) {
+		o.base = iri
+	}
+}
+
+// WithDocumentLoader sets the loader of remote contexts, DefaultDocumentLoader by default.
+func WithDocumentLoader(loader DocumentLoader) JSONLDOption {
+	return func(o *jsonldOptions) {
+		o.loader = loader
+	}
+}
+
+// WithExpandContext sets a context applied before the context of the documents, e.g. "https://schema.org" to
+// expand documents that lack a @context.
+func WithExpandContext(ctx interface{}) JSONLDOption {
+	return func(o *jsonldOptions) {
+		o.expandContext = ctx
+	}
+}
+
+// newJSONLDOptions returns the options with the given functions applied.
+func newJSONLDOptions(opts []JSONLDOption) jsonldOptions {
+	o := jsonldOptions{loader: DefaultDocumentLoader}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// initialContext returns the active context the algorithms start with.
+func (o jsonldOptions) initialContext() (*jsonldContext, error) {
+	var base *url.URL
+	if o.base != "" {
+		u, err := url.Parse(o.base)
+		if err != nil {
+			return nil, jsonldError("invalid base IRI", err)
+		}
+		base = u
+	}
+	active := newJSONLDContext(base, o.loader)
+
+	if o.expandContext != nil {
+		ctx := o.expandContext
+		if m, ok := ctx.(map[string]interface{}); ok {
+			if c, ok := m["@context"]; ok {
+				ctx = c
+			}
+		}
+		return active.process(ctx, base, nil, false, true, true)
+	}
+	return active, nil
+}
+
+// Expand implements the JSON-LD 1.1 Expansion algorithm, see https://www.w3.org/TR/json-ld11-api/#expansion. The
+// document is the decoded JSON, as returned by json.Unmarshal. Contexts are resolved with DefaultDocumentLoader
+// unless WithDocumentLoader is given. Errors are of type *JSONLDError.
+func Expand(doc interface{}, opts ...JSONLDOption) ([]interface{}, error) {
+	o := newJSONLDOptions(opts)
+	active, err := o.initialContext()
+	if err != nil {
+		return nil, err
+	}
+
+	expanded, err := (&expander{}).expand(active, "", doc, active.base, false)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := expanded.(map[string]interface{}); ok {
+		if graph, ok := m["@graph"]; ok && len(m) == 1 {
+			expanded = graph
+		}
+	}
+	return asArrayOrEmpty(expanded), nil
+}
+
+// WithJSONLDExpansion expands the JSON-LD scripts of documents, see Expand, and adds the expanded documents to
+// Microdata.JSONLD. Their items are read from the expanded form, see ExpandedItems, instead of the JSON as is. The
+// document URL is the base IRI unless WithBaseIRI is given. Scripts that fail to expand are read as is.
+func WithJSONLDExpansion(opts ...JSONLDOption) ParseOption {
+	return func(o *parseOptions) {
+		o.jsonldExpansion = true
+		o.jsonld = append(o.jsonld, opts...)
+	}
+}
+
+// readExpandedJSONLD expands the JSON-LD document and adds its items. It returns false if it fails to expand.
+func (p *parser) readExpandedJSONLD(doc interface{}) bool {
+	opts := append([]JSONLDOption{WithBaseIRI(p.baseURL.String())}, p.opts.jsonld...)
+	expanded, err := Expand(doc, opts...)
+	if err != nil {
+		log.Println("Error expanding json-ld:", err)
+		return false
+	}
+	p.data.JSONLD = append(p.data.JSONLD, expanded...)
+	for _, item := range ExpandedItems(expanded) {
+		p.data.addItem(item)
+	}
+	return true
+}
+
+// ExpandedItems returns the node objects of the expanded JSON-LD as items. Types and property names are full
+// IRIs, e.g. "http://schema.org/name", and the @id of a node is the item ID. Values are the @value of value
+// objects, the items of list objects are added in order and node objects, including the references that have an
+// @id only, are nested items. The nodes of @graph, @included and @reverse are nested items of the same name.
+func ExpandedItems(expanded []interface{}) []*Item {
+	var items []*Item
+	for _, v := range expanded {
+		if m, ok := v.(map[string]interface{}); ok && isNodeObject(m) {
+			items = append(items, expandedItem(m))
+		}
+	}
+	return items
+}
+
+// expandedItem returns the expanded node object as an item.
+func expandedItem(node map[string]interface{}) *Item {
+	item := NewItem()
+	if id, ok := node["@id"].(string); ok {
+		item.ID = id
+	}
+	for _, t := range asArrayOrEmpty(node["@type"]) {
+		if s, ok := t.(string); ok {
+			item.addType(s)
+		}
+	}
+
+	for _, key := range sortedKeys(node) {
+		switch key {
+		case "@id", "@type", "@index", "@context":
+		case "@graph", "@included":
+			for _, n := range ExpandedItems(asArray(node[key])) {
+				item.addItem(key, n)
+			}
+		case "@reverse":
+			if reverse, ok := node[key].(map[string]interface{}); ok {
+				item.addItem(key, expandedItem(reverse))
+			}
+		default:
+			if strings.HasPrefix(key, "@") {
+				continue
+			}
+			for _, v := range asArray(node[key]) {
+				addExpandedValue(item, key, v)
+			}
+		}
+	}
+	return item
+}
+
+// addExpandedValue adds the value of an expanded property to the item.
+func addExpandedValue(item *Item, key string, v interface{}) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+	switch {
+	case isValueObject(m):
+		if m["@value"] != nil {
+			item.addProperty(key, m["@value"])
+		}
+	case isListObject(m):
+		for _, e := range asArray(m["@list"]) {
+			addExpandedValue(item, key, e)
+		}
+	default:
+		item.addItem(key, expandedItem(m))
+	}
+}
+
+// schemaOrgHTTPS is the namespace of schema.org written with https, which the schema.org context maps to http.
+const schemaOrgHTTPS = "https://schema.org/"
+
+// Expand returns the items as expanded JSON-LD, see Expand. The items are converted to node objects with the
+// item ID as @id, and the properties of items that have absolute types are in the vocabulary of their first type,
+// e.g. https://schema.org/ for https://schema.org/Person, like in microdata. Untyped items nested in another item
+// are in its vocabulary, while top-level untyped items have none and are dropped, as the names of their properties
+// aren't IRIs. The values read from URL property elements, e.g. the href of <a>, are node references, like in the
+// microdata to RDF mapping. Items read from JSON-LD as is keep their @context. IRIs in the https://schema.org/
+// namespace are written with http, like in the schema.org context, so that the items of all sources are in the
+// same namespace. The document URL is the base IRI unless WithBaseIRI is given.
+func (m *Microdata) Expand(opts ...JSONLDOption) ([]interface{}, error) {
+	nodes := make([]interface{}, 0, len(m.Items))
+	path := make(map[*Item]bool)
+	for _, item := range m.Items {
+		nodes = append(nodes, itemNode(item, path))
+	}
+	return Expand(nodes, append([]JSONLDOption{WithBaseIRI(m.BaseURL)}, opts...)...)
+}
+
+// itemNode returns the item as a JSON-LD node object. Items already in path are written as node references, to
+// break cycles.
+func itemNode(item *Item, path map[*Item]bool) map[string]interface{} {
+	if _, ok := item.Properties["@value"]; ok {
+		return valueNode(item)
+	}
+
+	node := make(map[string]interface{})
+	if item.ID != "" {
+		node["@id"] = schemaOrgHTTP(item.ID)
+	}
+	if path[item] {
+		return node
+	}
+	path[item] = true
+	defer delete(path, item)
+
+	if len(item.Types) > 0 {
+		types := make([]interface{}, len(item.Types))
+		for i, t := range item.Types {
+			types[i] = schemaOrgHTTP(t)
+		}
+		node["@type"] = types
+		if vocab, _ := splitIRI(types[0].(string)); isAbsoluteIRI(vocab) {
+			node["@context"] = map[string]interface{}{"@vocab": vocab}
+		}
+	}
+
+	for key, values := range item.Properties {
+		switch key {
+		case "@context":
+			if len(values) > 0 {
+				node[key] = plainJSON(values[0])
+			}
+			continue
+		case "@id":
+			if len(values) > 0 && item.ID == "" {
+				if s, ok := values[0].(string); ok {
+					node[key] = s
+				}
+			}
+			continue
+		case "@language", "@direction", "@index":
+			if len(values) > 0 {
+				node[key] = plainJSON(values[0])
+			}
+			continue
+		}
+
+		a := make([]interface{}, 0, len(values))
+		for _, v := range values {
+			switch v := v.(type) {
+			case *Item:
+				a = append(a, itemNode(v, path))
+			case string:
+				if item.isURL(key, v) {
+					a = append(a, map[string]interface{}{"@id": v})
+				} else {
+					a = append(a, v)
+				}
+			case bool, float64, int:
+				a = append(a, v)
+			default:
+				a = append(a, fmt.Sprint(v))
+			}
+		}
+		node[schemaOrgHTTP(key)] = a
+	}
+	return node
+}
+
+// valueNode returns the item read from a JSON-LD value object as is, e.g. {"@value": "v", "@language": "en"}, back
+// as a value object, with its keyword entries as scalars.
+func valueNode(item *Item) map[string]interface{} {
+	node := plainJSON(item).(map[string]interface{})
+	if t, ok := node["@type"].(string); ok {
+		node["@type"] = schemaOrgHTTP(t)
+	}
+	return node
+}
+
+// plainJSON returns the value read from JSON as is, e.g. an inline @context, back as JSON.
+func plainJSON(v interface{}) interface{} {
+	return plainJSONPath(v, make(map[*Item]bool))
+}
+
+// plainJSONPath returns the value back as JSON. Items already in path are written as node references, to break
+// cycles.
+func plainJSONPath(v interface{}, path map[*Item]bool) interface{} {
+	item, ok := v.(*Item)
+	if !ok {
+		return v
+	}
+	if path[item] {
+		return map[string]interface{}{"@id": item.ID}
+	}
+	path[item] = true
+	defer delete(path, item)
+
+	m := make(map[string]interface{}, len(item.Properties)+1)
+	if len(item.Types) == 1 {
+		m["@type"] = item.Types[0]
+	}
+	for key, values := range item.Properties {
+		if len(values) == 1 {
+			m[key] = plainJSONPath(values[0], path)
+			continue
+		}
+		a := make([]interface{}, len(values))
+		for i, v := range values {
+			a[i] = plainJSONPath(v, path)
+		}
+		m[key] = a
+	}
+	return m
+}
+
+// schemaOrgHTTP returns the IRI in the http://schema.org/ namespace if it is in the https one.
+func schemaOrgHTTP(iri string) string {
+	if strings.HasPrefix(iri, schemaOrgHTTPS) {
+		return "http://schema.org/" + iri[len(schemaOrgHTTPS):]
+	}
+	return iri
+}
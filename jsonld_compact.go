@@ -0,0 +1,868 @@
+package microdata
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// inverseContext maps IRIs to containers to "@language", "@type" or "@any" to the language, type or "@none" to
+// the term to use, see https://www.w3.org/TR/json-ld11-api/#inverse-context-creation.
+type inverseContext map[string]map[string]map[string]map[string]string
+
+// Compact compacts the items of the microdata with the given context, see CompactJSONLD. The context is a context
+// URL, e.g. "https://schema.org", a context definition or a document with a @context entry.
+func Compact(data *Microdata, context interface{}, opts ...JSONLDOption) (map[string]interface{}, error) {
+	expanded, err := data.Expand(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return CompactJSONLD(expanded, context, append([]JSONLDOption{WithBaseIRI(data.BaseURL)}, opts...)...)
+}
+
+// CompactJSONLD implements the JSON-LD 1.1 Compaction algorithm, see https://www.w3.org/TR/json-ld11-api/#compaction.
+// The document is expanded first, so that it may be in any form. The result has the given context as @context and
+// its top-level nodes in a @graph entry unless there is only one. Errors are of type *JSONLDError.
+func CompactJSONLD(doc interface{}, context interface{}, opts ...JSONLDOption) (map[string]interface{}, error) {
+	expanded, err := Expand(doc, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	active, context, err := compactionContext(context, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	compacted, err := compact(active, "", expanded, true)
+	if err != nil {
+		return nil, err
+	}
+
+	result, ok := compacted.(map[string]interface{})
+	if !ok {
+		result = make(map[string]interface{})
+		if a, _ := compacted.([]interface{}); len(a) > 0 {
+			result[active.compactIRI("@graph", nil, true, false)] = a
+		}
+	}
+	if !isEmptyContext(context) {
+		result["@context"] = context
+	}
+	return result, nil
+}
+
+// compactionContext returns the active context to compact with the given context, and the context itself when it
+// was given as a document with a @context entry.
+func compactionContext(context interface{}, opts []JSONLDOption) (*jsonldContext, interface{}, error) {
+	if m, ok := context.(map[string]interface{}); ok {
+		if c, ok := m["@context"]; ok {
+			context = c
+		}
+	}
+	o := newJSONLDOptions(opts)
+	active, err := o.initialContext()
+	if err != nil {
+		return nil, nil, err
+	}
+	if active, err = active.process(context, active.base, nil, false, true, true); err != nil {
+		return nil, nil, err
+	}
+	return active, context, nil
+}
+
+// isEmptyContext returns true if the context is null, an empty map or an empty array.
+func isEmptyContext(context interface{}) bool {
+	switch c := context.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(c) == 0
+	case []interface{}:
+		return len(c) == 0
+	}
+	return false
+}
+
+// compact implements the Compaction algorithm, see https://www.w3.org/TR/json-ld11-api/#compaction-algorithm.
+func compact(active *jsonldContext, property string, element interface{}, compactArrays bool) (interface{}, error) {
+	typeScoped := active
+	def := active.terms[property]
+
+	switch v := element.(type) {
+	case []interface{}:
+		result := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			compacted, err := compact(active, property, item, compactArrays)
+			if err != nil {
+				return nil, err
+			}
+			if compacted != nil {
+				result = append(result, compacted)
+			}
+		}
+		if len(result) != 1 || !compactArrays || property == "@graph" || property == "@set" ||
+			def.hasContainer("@list") || def.hasContainer("@set") {
+			return result, nil
+		}
+		return result[0], nil
+	case map[string]interface{}:
+		return compactObject(active, typeScoped, property, v, compactArrays)
+	default:
+		return element, nil
+	}
+}
+
+// compactObject compacts a map of the Compaction algorithm.
+func compactObject(active, typeScoped *jsonldContext, property string,
+	element map[string]interface{}, compactArrays bool) (interface{}, error) {
+	_, hasValue := element["@value"]
+	_, hasID := element["@id"]
+	if active.previous != nil && !hasValue && !(hasID && len(element) == 1) {
+		active = active.previous
+	}
+
+	def := active.terms[property]
+	if def != nil && def.hasContext {
+		var err error
+		if active, err = active.process(def.context, def.baseURL, nil, true, true, true); err != nil {
+			return nil, err
+		}
+		def = active.terms[property]
+	}
+
+	_, hasIndex := element["@index"]
+	if hasValue || (hasID && (len(element) == 1 || (len(element) == 2 && hasIndex))) {
+		v := active.compactValue(property, element)
+		if _, ok := v.(map[string]interface{}); !ok || (def != nil && def.typ == "@json") {
+			return v, nil
+		}
+	}
+
+	if isListObject(element) && def.hasContainer("@list") {
+		return compact(active, property, element["@list"], compactArrays)
+	}
+
+	insideReverse := property == "@reverse"
+	result := make(map[string]interface{})
+
+	if types, ok := element["@type"]; ok {
+		var compactedTypes []string
+		for _, t := range asArray(types) {
+			if s, ok := t.(string); ok {
+				compactedTypes = append(compactedTypes, typeScoped.compactIRI(s, nil, true, false))
+			}
+		}
+		sort.Strings(compactedTypes)
+		for _, t := range compactedTypes {
+			if d := typeScoped.terms[t]; d != nil && d.hasContext {
+				var err error
+				if active, err = active.process(d.context, d.baseURL, nil, false, false, true); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	for _, expandedProperty := range sortedKeys(element) {
+		expandedValue := element[expandedProperty]
+
+		switch expandedProperty {
+		case "@id":
+			if s, ok := expandedValue.(string); ok {
+				expandedValue = active.compactIRI(s, nil, false, false)
+			}
+			result[active.compactIRI("@id", nil, true, false)] = expandedValue
+			continue
+		case "@type":
+			var compacted []interface{}
+			for _, t := range asArray(expandedValue) {
+				if s, ok := t.(string); ok {
+					compacted = append(compacted, typeScoped.compactIRI(s, nil, true, false))
+				}
+			}
+			alias := active.compactIRI("@type", nil, true, false)
+			asArr := !compactArrays || active.terms[alias].hasContainer("@set")
+			var value interface{} = compacted
+			if len(compacted) == 1 {
+				value = compacted[0]
+			}
+			addCompactValue(result, alias, value, asArr)
+			continue
+		case "@reverse":
+			compacted, err := compact(active, "@reverse", expandedValue, compactArrays)
+			if err != nil {
+				return nil, err
+			}
+			m, _ := compacted.(map[string]interface{})
+			for _, p := range sortedKeys(m) {
+				if d := active.terms[p]; d != nil && d.reverse {
+					addCompactValue(result, p, m[p], d.hasContainer("@set") || !compactArrays)
+					delete(m, p)
+				}
+			}
+			if len(m) > 0 {
+				result[active.compactIRI("@reverse", nil, true, false)] = m
+			}
+			continue
+		case "@preserve":
+			compacted, err := compact(active, property, expandedValue, compactArrays)
+			if err != nil {
+				return nil, err
+			}
+			if a, ok := compacted.([]interface{}); !ok || len(a) > 0 {
+				result["@preserve"] = compacted
+			}
+			continue
+		case "@index":
+			if def.hasContainer("@index") {
+				continue
+			}
+			result[active.compactIRI("@index", nil, true, false)] = expandedValue
+			continue
+		case "@direction", "@language", "@value":
+			result[active.compactIRI(expandedProperty, nil, true, false)] = expandedValue
+			continue
+		}
+
+		items := asArray(expandedValue)
+		if len(items) == 0 {
+			itemProperty := active.compactIRI(expandedProperty, expandedValue, true, insideReverse)
+			nestResult, err := active.nestResult(result, itemProperty)
+			if err != nil {
+				return nil, err
+			}
+			addCompactValue(nestResult, itemProperty, []interface{}{}, true)
+			continue
+		}
+
+		for _, expandedItem := range items {
+			itemProperty := active.compactIRI(expandedProperty, expandedItem, true, insideReverse)
+			nestResult, err := active.nestResult(result, itemProperty)
+			if err != nil {
+				return nil, err
+			}
+			if err := compactItem(active, nestResult, itemProperty, expandedItem, compactArrays); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// compactItem compacts a value of an expanded property into the result, under the given compacted property.
+func compactItem(active *jsonldContext, result map[string]interface{}, itemProperty string,
+	expandedItem interface{}, compactArrays bool) error {
+	def := active.terms[itemProperty]
+	asArr := def.hasContainer("@set") || itemProperty == "@graph" || itemProperty == "@list" || !compactArrays
+
+	item, _ := expandedItem.(map[string]interface{})
+	toCompact := expandedItem
+	isList, isGraph := isListObject(item), isGraphObject(item)
+	if isList {
+		toCompact = item["@list"]
+	} else if isGraph {
+		toCompact = item["@graph"]
+	}
+	compactedItem, err := compact(active, itemProperty, toCompact, compactArrays)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case isList:
+		compactedItem = asArrayOrEmpty(compactedItem)
+		if !def.hasContainer("@list") {
+			wrapped := map[string]interface{}{active.compactIRI("@list", nil, true, false): compactedItem}
+			if index, ok := item["@index"]; ok {
+				wrapped[active.compactIRI("@index", nil, true, false)] = index
+			}
+			addCompactValue(result, itemProperty, wrapped, asArr)
+		} else {
+			result[itemProperty] = compactedItem
+		}
+	case isGraph:
+		id, hasID := item["@id"].(string)
+		index, hasIndex := item["@index"].(string)
+		switch {
+		case def.hasContainer("@graph") && def.hasContainer("@id"):
+			mapObject := mapEntry(result, itemProperty)
+			mapKey := active.compactIRI("@none", nil, true, false)
+			if hasID {
+				mapKey = active.compactIRI(id, nil, false, false)
+			}
+			addCompactValue(mapObject, mapKey, compactedItem, asArr)
+		case def.hasContainer("@graph") && def.hasContainer("@index") && !hasID:
+			mapObject := mapEntry(result, itemProperty)
+			mapKey := active.compactIRI("@none", nil, true, false)
+			if hasIndex {
+				mapKey = index
+			}
+			addCompactValue(mapObject, mapKey, compactedItem, asArr)
+		case def.hasContainer("@graph") && !hasID:
+			if a, ok := compactedItem.([]interface{}); ok && len(a) > 1 {
+				compactedItem = map[string]interface{}{active.compactIRI("@included", nil, true, false): a}
+			}
+			addCompactValue(result, itemProperty, compactedItem, asArr)
+		default:
+			wrapped := map[string]interface{}{active.compactIRI("@graph", nil, true, false): compactedItem}
+			if hasID {
+				wrapped[active.compactIRI("@id", nil, true, false)] = active.compactIRI(id, nil, false, false)
+			}
+			if hasIndex {
+				wrapped[active.compactIRI("@index", nil, true, false)] = index
+			}
+			addCompactValue(result, itemProperty, wrapped, asArr)
+		}
+	case def.hasContainer("@language") || def.hasContainer("@index") || def.hasContainer("@id") ||
+		def.hasContainer("@type"):
+		mapObject := mapEntry(result, itemProperty)
+		var containerKeyword string
+		for _, k := range []string{"@language", "@index", "@id", "@type"} {
+			if def.hasContainer(k) {
+				containerKeyword = k
+				break
+			}
+		}
+		containerKey := active.compactIRI(containerKeyword, nil, true, false)
+		indexKey := def.index
+		if indexKey == "" {
+			indexKey = "@index"
+		}
+
+		var mapKey string
+		compactedMap, _ := compactedItem.(map[string]interface{})
+		switch {
+		case containerKeyword == "@language":
+			if m, ok := compactedItem.(map[string]interface{}); ok && isValueObject(item) {
+				compactedItem = m[active.compactIRI("@value", nil, true, false)]
+			}
+			mapKey, _ = item["@language"].(string)
+		case containerKeyword == "@index" && indexKey == "@index":
+			mapKey, _ = item["@index"].(string)
+		case containerKeyword == "@index":
+			containerKey = active.compactIRI(indexKey, nil, true, false)
+			mapKey = popFirstString(compactedMap, containerKey)
+		case containerKeyword == "@id":
+			mapKey, _ = compactedMap[containerKey].(string)
+			delete(compactedMap, containerKey)
+		case containerKeyword == "@type":
+			mapKey = popFirstString(compactedMap, containerKey)
+			if len(compactedMap) == 1 {
+				for k := range compactedMap {
+					if iri, _ := active.expandIRI(k, false, true, nil, nil); iri == "@id" {
+						if compactedItem, err = compact(active, itemProperty,
+							map[string]interface{}{"@id": item["@id"]}, compactArrays); err != nil {
+							return err
+						}
+					}
+				}
+			}
+		}
+		if mapKey == "" {
+			mapKey = active.compactIRI("@none", nil, true, false)
+		}
+		addCompactValue(mapObject, mapKey, compactedItem, asArr)
+	default:
+		addCompactValue(result, itemProperty, compactedItem, asArr)
+	}
+	return nil
+}
+
+// nestResult returns the map the values of the compacted property are added to: the result, or the entry of its
+// @nest term.
+func (c *jsonldContext) nestResult(result map[string]interface{}, property string) (map[string]interface{}, error) {
+	def := c.terms[property]
+	if def == nil || def.nest == "" {
+		return result, nil
+	}
+	if iri, _ := c.expandIRI(def.nest, false, true, nil, nil); iri != "@nest" {
+		return nil, jsonldError("invalid @nest value", nil)
+	}
+	return mapEntry(result, def.nest), nil
+}
+
+// mapEntry returns the map of the given entry, creating it if needed.
+func mapEntry(m map[string]interface{}, key string) map[string]interface{} {
+	entry, ok := m[key].(map[string]interface{})
+	if !ok {
+		entry = make(map[string]interface{})
+		m[key] = entry
+	}
+	return entry
+}
+
+// popFirstString removes the first value of the entry of the map and returns it if it is a string. The entry is
+// removed when it has no values left.
+func popFirstString(m map[string]interface{}, key string) string {
+	values := asArrayOrEmpty(m[key])
+	if len(values) == 0 {
+		return ""
+	}
+	first, _ := values[0].(string)
+	switch len(values) {
+	case 1:
+		delete(m, key)
+	case 2:
+		m[key] = values[1]
+	default:
+		m[key] = values[1:]
+	}
+	return first
+}
+
+// addCompactValue adds the value to the entry of the map. The entry is an array when it has multiple values or
+// asArray is true.
+func addCompactValue(m map[string]interface{}, key string, value interface{}, forceArray bool) {
+	existing, ok := m[key]
+	if forceArray {
+		if !ok {
+			existing = []interface{}{}
+		} else if _, isArray := existing.([]interface{}); !isArray {
+			existing = []interface{}{existing}
+		}
+		m[key], ok = existing, true
+	}
+	if a, isArray := value.([]interface{}); isArray {
+		for _, v := range a {
+			addCompactValue(m, key, v, forceArray)
+		}
+		return
+	}
+	if !ok {
+		m[key] = value
+		return
+	}
+	m[key] = append(asArray(existing), value)
+}
+
+// inverseContext returns the inverse context of the active context, creating it on first use.
+func (c *jsonldContext) inverseContext() inverseContext {
+	if c.inverse != nil {
+		return c.inverse
+	}
+	result := make(inverseContext)
+	defaultLanguage := "@none"
+	if c.language != "" {
+		defaultLanguage = c.language
+	}
+
+	terms := make([]string, 0, len(c.terms))
+	for t := range c.terms {
+		terms = append(terms, t)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if len(terms[i]) != len(terms[j]) {
+			return len(terms[i]) < len(terms[j])
+		}
+		return terms[i] < terms[j]
+	})
+
+	for _, term := range terms {
+		def := c.terms[term]
+		if def == nil || def.id == "" {
+			continue
+		}
+		container := "@none"
+		if len(def.container) > 0 {
+			sorted := append([]string(nil), def.container...)
+			sort.Strings(sorted)
+			container = strings.Join(sorted, "")
+		}
+
+		containerMap, ok := result[def.id]
+		if !ok {
+			containerMap = make(map[string]map[string]map[string]string)
+			result[def.id] = containerMap
+		}
+		typeLanguageMap, ok := containerMap[container]
+		if !ok {
+			typeLanguageMap = map[string]map[string]string{
+				"@language": {},
+				"@type":     {},
+				"@any":      {"@none": term},
+			}
+			containerMap[container] = typeLanguageMap
+		}
+		languageMap, typeMap := typeLanguageMap["@language"], typeLanguageMap["@type"]
+		setFirstTerm := func(m map[string]string, key string) {
+			if _, ok := m[key]; !ok {
+				m[key] = term
+			}
+		}
+
+		switch {
+		case def.reverse:
+			setFirstTerm(typeMap, "@reverse")
+		case def.typ == "@none":
+			setFirstTerm(languageMap, "@any")
+			setFirstTerm(typeMap, "@any")
+		case def.typ != "":
+			setFirstTerm(typeMap, def.typ)
+		case def.language != nil && def.direction != nil:
+			setFirstTerm(languageMap, languageDirection(*def.language, *def.direction, "@null"))
+		case def.language != nil:
+			lang := *def.language
+			if lang == "" {
+				lang = "@null"
+			}
+			setFirstTerm(languageMap, lang)
+		case def.direction != nil:
+			dir := "@none"
+			if *def.direction != "" {
+				dir = "_" + *def.direction
+			}
+			setFirstTerm(languageMap, dir)
+		case c.direction != "":
+			setFirstTerm(languageMap, languageDirection(c.language, c.direction, "@none"))
+			setFirstTerm(languageMap, "@none")
+			setFirstTerm(typeMap, "@none")
+		default:
+			setFirstTerm(languageMap, defaultLanguage)
+			setFirstTerm(languageMap, "@none")
+			setFirstTerm(typeMap, "@none")
+		}
+	}
+	c.inverse = result
+	return result
+}
+
+// languageDirection returns the inverse context key of a language and direction, or null when both are empty.
+func languageDirection(language, direction, null string) string {
+	switch {
+	case language != "" && direction != "":
+		return strings.ToLower(language) + "_" + direction
+	case language != "":
+		return strings.ToLower(language)
+	case direction != "":
+		return "_" + direction
+	}
+	return null
+}
+
+// selectTerm implements the Term Selection algorithm, see https://www.w3.org/TR/json-ld11-api/#term-selection.
+func (c *jsonldContext) selectTerm(iri string, containers []string, typeLanguage string, preferred []string) string {
+	containerMap := c.inverseContext()[iri]
+	for _, container := range containers {
+		typeLanguageMap, ok := containerMap[container]
+		if !ok {
+			continue
+		}
+		valueMap := typeLanguageMap[typeLanguage]
+		for _, p := range preferred {
+			if term, ok := valueMap[p]; ok {
+				return term
+			}
+		}
+	}
+	return ""
+}
+
+// compactIRI implements the IRI Compaction algorithm, see https://www.w3.org/TR/json-ld11-api/#iri-compaction.
+func (c *jsonldContext) compactIRI(iri string, value interface{}, vocab, reverse bool) string {
+	if iri == "" {
+		return ""
+	}
+
+	if _, ok := c.inverseContext()[iri]; vocab && ok {
+		if term := c.selectCompactTerm(iri, value, reverse); term != "" {
+			return term
+		}
+	}
+
+	if vocab && c.hasVocab && strings.HasPrefix(iri, c.vocab) && len(iri) > len(c.vocab) {
+		suffix := iri[len(c.vocab):]
+		if _, ok := c.terms[suffix]; !ok {
+			return suffix
+		}
+	}
+
+	var compactIRI string
+	for _, term := range sortedTermKeys(c.terms) {
+		def := c.terms[term]
+		if def == nil || def.id == "" || def.id == iri || !strings.HasPrefix(iri, def.id) || !def.prefix {
+			continue
+		}
+		candidate := term + ":" + iri[len(def.id):]
+		if compactIRI != "" && (len(candidate) > len(compactIRI) ||
+			(len(candidate) == len(compactIRI) && candidate >= compactIRI)) {
+			continue
+		}
+		if d, ok := c.terms[candidate]; !ok || (d != nil && d.id == iri && value == nil) {
+			compactIRI = candidate
+		}
+	}
+	if compactIRI != "" {
+		return compactIRI
+	}
+
+	if !vocab && c.base != nil {
+		return relativeIRI(c.base, iri)
+	}
+	return iri
+}
+
+// selectCompactTerm returns the term selected for the IRI and its value, "" if there is none.
+func (c *jsonldContext) selectCompactTerm(iri string, value interface{}, reverse bool) string {
+	defaultLanguage := "@none"
+	if c.direction != "" {
+		defaultLanguage = languageDirection(c.language, c.direction, "@none")
+	} else if c.language != "" {
+		defaultLanguage = c.language
+	}
+
+	v, _ := value.(map[string]interface{})
+	if preserve, ok := v["@preserve"]; ok {
+		v, _ = asArray(preserve)[0].(map[string]interface{})
+	}
+	_, hasIndex := v["@index"]
+
+	var containers []string
+	typeLanguage, typeLanguageValue := "@language", "@null"
+	if hasIndex && !isGraphObject(v) {
+		containers = append(containers, "@index", "@index@set")
+	}
+
+	switch {
+	case reverse:
+		typeLanguage, typeLanguageValue = "@type", "@reverse"
+		containers = append(containers, "@set")
+	case isListObject(v):
+		if !hasIndex {
+			containers = append(containers, "@list")
+		}
+		list := asArrayOrEmpty(v["@list"])
+		var commonType, commonLanguage string
+		if len(list) == 0 {
+			commonLanguage = defaultLanguage
+		}
+		for _, item := range list {
+			itemLanguage, itemType := "@none", "@none"
+			if m, ok := item.(map[string]interface{}); ok && isValueObject(m) {
+				lang, _ := m["@language"].(string)
+				dir, _ := m["@direction"].(string)
+				if t, ok := m["@type"].(string); ok {
+					itemType = t
+				} else {
+					itemLanguage = languageDirection(lang, dir, "@null")
+				}
+			} else {
+				itemType = "@id"
+			}
+			if commonLanguage == "" {
+				commonLanguage = itemLanguage
+			} else if itemLanguage != commonLanguage && isValueObject(item) {
+				commonLanguage = "@none"
+			}
+			if commonType == "" {
+				commonType = itemType
+			} else if itemType != commonType {
+				commonType = "@none"
+			}
+			if commonLanguage == "@none" && commonType == "@none" {
+				break
+			}
+		}
+		if commonLanguage == "" {
+			commonLanguage = "@none"
+		}
+		if commonType == "" {
+			commonType = "@none"
+		}
+		if commonType != "@none" {
+			typeLanguage, typeLanguageValue = "@type", commonType
+		} else {
+			typeLanguageValue = commonLanguage
+		}
+	case isGraphObject(v):
+		_, hasID := v["@id"]
+		if hasIndex {
+			containers = append(containers, "@graph@index", "@graph@index@set")
+		}
+		if hasID {
+			containers = append(containers, "@graph@id", "@graph@id@set")
+		}
+		containers = append(containers, "@graph", "@graph@set", "@set")
+		if !hasIndex {
+			containers = append(containers, "@graph@index", "@graph@index@set")
+		}
+		if !hasID {
+			containers = append(containers, "@graph@id", "@graph@id@set")
+		}
+		containers = append(containers, "@index", "@index@set")
+		typeLanguage, typeLanguageValue = "@type", "@id"
+	default:
+		if isValueObject(v) {
+			lang, hasLanguage := v["@language"].(string)
+			dir, hasDirection := v["@direction"].(string)
+			if (hasLanguage || hasDirection) && !hasIndex {
+				typeLanguageValue = languageDirection(lang, dir, "@null")
+				containers = append(containers, "@language", "@language@set")
+			} else if t, ok := v["@type"].(string); ok {
+				typeLanguage, typeLanguageValue = "@type", t
+			}
+		} else {
+			typeLanguage, typeLanguageValue = "@type", "@id"
+			containers = append(containers, "@id", "@id@set", "@type", "@set@type")
+		}
+		containers = append(containers, "@set")
+	}
+
+	containers = append(containers, "@none")
+	if !hasIndex {
+		containers = append(containers, "@index", "@index@set")
+	}
+	if isValueObject(v) && len(v) == 1 {
+		containers = append(containers, "@language", "@language@set")
+	}
+
+	var preferred []string
+	if typeLanguageValue == "@reverse" {
+		preferred = append(preferred, "@reverse")
+	}
+	if id, ok := v["@id"].(string); ok && (typeLanguageValue == "@id" || typeLanguageValue == "@reverse") {
+		if d := c.terms[c.compactIRI(id, nil, true, false)]; d != nil && d.id == id {
+			preferred = append(preferred, "@vocab", "@id", "@none")
+		} else {
+			preferred = append(preferred, "@id", "@vocab", "@none")
+		}
+	} else {
+		preferred = append(preferred, typeLanguageValue)
+		if list, ok := v["@list"].([]interface{}); ok && len(list) == 0 {
+			typeLanguage = "@any"
+		}
+		preferred = append(preferred, "@none")
+	}
+	preferred = append(preferred, "@any")
+	for _, p := range preferred {
+		if i := strings.Index(p, "_"); i >= 0 {
+			preferred = append(preferred, p[i:])
+			break
+		}
+	}
+	return c.selectTerm(iri, containers, typeLanguage, preferred)
+}
+
+// compactValue implements the Value Compaction algorithm, see https://www.w3.org/TR/json-ld11-api/#value-compaction.
+func (c *jsonldContext) compactValue(property string, value map[string]interface{}) interface{} {
+	def := c.terms[property]
+	language, direction := c.language, c.direction
+	if def != nil && def.language != nil {
+		language = *def.language
+	}
+	if def != nil && def.direction != nil {
+		direction = *def.direction
+	}
+	var typ string
+	if def != nil {
+		typ = def.typ
+	}
+
+	_, hasIndex := value["@index"]
+	_, hasID := value["@id"]
+	valueType, hasType := value["@type"]
+
+	var result interface{} = value
+	switch {
+	case hasID && (len(value) == 1 || (len(value) == 2 && hasIndex)):
+		id, _ := value["@id"].(string)
+		if typ == "@id" {
+			result = c.compactIRI(id, nil, false, false)
+		} else if typ == "@vocab" {
+			result = c.compactIRI(id, nil, true, false)
+		}
+	case hasType && valueType == typ:
+		result = value["@value"]
+	case typ == "@none" || hasType:
+		if hasType {
+			m := copyMap(value)
+			if s, ok := valueType.(string); ok {
+				m["@type"] = c.compactIRI(s, nil, true, false)
+			}
+			result = m
+		}
+	default:
+		if _, ok := value["@value"].(string); !ok {
+			if !hasIndex || def.hasContainer("@index") {
+				result = value["@value"]
+			}
+			break
+		}
+		lang, _ := value["@language"].(string)
+		dir, _ := value["@direction"].(string)
+		if strings.EqualFold(lang, language) && dir == direction && (!hasIndex || def.hasContainer("@index")) {
+			result = value["@value"]
+		}
+	}
+
+	if m, ok := result.(map[string]interface{}); ok {
+		compacted := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			compacted[c.compactIRI(k, nil, true, false)] = v
+		}
+		result = compacted
+	}
+	return result
+}
+
+// copyMap returns a shallow copy of the map.
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	r := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		r[k] = v
+	}
+	return r
+}
+
+// sortedTermKeys returns the terms of the definitions in lexicographical order.
+func sortedTermKeys(terms map[string]*termDefinition) []string {
+	keys := make([]string, 0, len(terms))
+	for k := range terms {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// relativeIRI returns the IRI relative to the base, or as is when they don't share the scheme and authority.
+func relativeIRI(base *url.URL, iri string) string {
+	u, err := url.Parse(iri)
+	if err != nil || u.Scheme != base.Scheme || u.Host != base.Host || u.User.String() != base.User.String() {
+		return iri
+	}
+
+	suffix := ""
+	if u.RawQuery != "" || u.ForceQuery {
+		suffix = "?" + u.RawQuery
+	}
+	if u.Fragment != "" {
+		suffix += "#" + u.EscapedFragment()
+	}
+	if u.EscapedPath() == base.EscapedPath() {
+		if u.RawQuery == base.RawQuery && u.Fragment != "" {
+			return "#" + u.EscapedFragment()
+		}
+		if suffix != "" && u.RawQuery != "" {
+			return suffix
+		}
+	}
+
+	baseSegments := strings.Split(base.EscapedPath(), "/")
+	segments := strings.Split(u.EscapedPath(), "/")
+	baseDir := baseSegments[:len(baseSegments)-1]
+	common := 0
+	for common < len(baseDir) && common < len(segments)-1 && baseDir[common] == segments[common] {
+		common++
+	}
+
+	var b strings.Builder
+	for i := common; i < len(baseDir); i++ {
+		b.WriteString("../")
+	}
+	rest := strings.Join(segments[common:], "/")
+	if b.Len() == 0 && (rest == "" || strings.Contains(strings.SplitN(rest, "/", 2)[0], ":")) {
+		b.WriteString("./")
+	}
+	b.WriteString(rest)
+	return b.String() + suffix
+}
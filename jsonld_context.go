@@ -0,0 +1,743 @@
+package microdata
+
+import (
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxRemoteContexts is the maximum number of nested remote contexts loaded while processing a context.
+const maxRemoteContexts = 32
+
+// jsonldKeywords are the keywords of JSON-LD 1.1, including the ones of framing.
+var jsonldKeywords = map[string]bool{
+	"@base": true, "@container": true, "@context": true, "@direction": true, "@graph": true, "@id": true,
+	"@import": true, "@included": true, "@index": true, "@json": true, "@language": true, "@list": true,
+	"@nest": true, "@none": true, "@prefix": true, "@propagate": true, "@protected": true, "@reverse": true,
+	"@set": true, "@type": true, "@value": true, "@version": true, "@vocab": true,
+	"@default": true, "@embed": true, "@explicit": true, "@omitDefault": true, "@requireAll": true,
+}
+
+// keywordForm matches the strings having the form of a keyword, which are reserved for future use.
+var keywordForm = regexp.MustCompile(`^@[a-zA-Z]+$`)
+
+// iriScheme matches the scheme of an absolute IRI.
+var iriScheme = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+
+// isKeyword returns true if s is a JSON-LD keyword.
+func isKeyword(s string) bool {
+	return jsonldKeywords[s]
+}
+
+// isAbsoluteIRI returns true if s is an absolute IRI.
+func isAbsoluteIRI(s string) bool {
+	return iriScheme.MatchString(s)
+}
+
+// jsonldContext is an active context of the JSON-LD processing algorithms.
+type jsonldContext struct {
+	// base is the base IRI, nil when it was set to null.
+	base *url.URL
+	// originalBase is the base IRI of the document, restored when the context is reset with null.
+	originalBase *url.URL
+	vocab        string
+	hasVocab     bool
+	// language and direction are the defaults, "" when not set.
+	language  string
+	direction string
+	// terms holds the term definitions. A nil definition decouples the term from the vocabulary mapping.
+	terms map[string]*termDefinition
+	// previous is the context to revert to for new node objects when this one isn't propagated.
+	previous *jsonldContext
+	loader   DocumentLoader
+	// inverse is the inverse context used by compaction, created on first use.
+	inverse inverseContext
+}
+
+// termDefinition is the definition of a term of an active context.
+type termDefinition struct {
+	// id is the IRI mapping, a keyword or "" when the term maps to null.
+	id      string
+	reverse bool
+	// typ is the type mapping, "" when not set.
+	typ string
+	// language and direction are nil when not set and point to "" when set to null.
+	language  *string
+	direction *string
+	container []string
+	context   interface{}
+	// hasContext tells whether the term has a scoped context, as context can be null.
+	hasContext bool
+	baseURL    *url.URL
+	index      string
+	nest       string
+	prefix     bool
+	protected  bool
+}
+
+// hasContainer returns true if the definition has the given container mapping.
+func (d *termDefinition) hasContainer(container string) bool {
+	return d != nil && containsString(d.container, container)
+}
+
+// equal returns true if the definitions are the same, ignoring the protected flag.
+func (d *termDefinition) equal(o *termDefinition) bool {
+	if d == nil || o == nil {
+		return d == o
+	}
+	return d.id == o.id && d.reverse == o.reverse && d.typ == o.typ && equalStringPtr(d.language, o.language) &&
+		equalStringPtr(d.direction, o.direction) && strings.Join(d.container, " ") == strings.Join(o.container, " ") &&
+		d.hasContext == o.hasContext && reflect.DeepEqual(d.context, o.context) && d.index == o.index && d.nest == o.nest &&
+		d.prefix == o.prefix
+}
+
+// newJSONLDContext returns an empty active context with the given base IRI.
+func newJSONLDContext(base *url.URL, loader DocumentLoader) *jsonldContext {
+	return &jsonldContext{
+		base:         base,
+		originalBase: base,
+		terms:        make(map[string]*termDefinition),
+		loader:       loader,
+	}
+}
+
+// clone returns a copy of the context. Term definitions aren't modified once created and are shared.
+func (c *jsonldContext) clone() *jsonldContext {
+	r := *c
+	r.terms = make(map[string]*termDefinition, len(c.terms))
+	for k, v := range c.terms {
+		r.terms[k] = v
+	}
+	r.inverse = nil
+	return &r
+}
+
+// process implements the Context Processing algorithm, see https://www.w3.org/TR/json-ld11-api/#context-processing-algorithm.
+func (c *jsonldContext) process(local interface{}, baseURL *url.URL, remoteContexts []string, overrideProtected,
+	propagate, validateScoped bool) (*jsonldContext, error) {
+	result := c.clone()
+	if m, ok := local.(map[string]interface{}); ok {
+		if v, ok := m["@propagate"].(bool); ok {
+			propagate = v
+		}
+	}
+	if !propagate && result.previous == nil {
+		result.previous = c
+	}
+
+	for _, ctx := range asArray(local) {
+		switch v := ctx.(type) {
+		case nil:
+			if !overrideProtected {
+				for _, d := range result.terms {
+					if d != nil && d.protected {
+						return nil, jsonldError("invalid context nullification", nil)
+					}
+				}
+			}
+			prev := result
+			result = newJSONLDContext(c.originalBase, c.loader)
+			if !propagate {
+				result.previous = prev
+			}
+			continue
+		case string:
+			contextURL := resolveIRI(baseURL, v)
+			if !validateScoped && containsString(remoteContexts, contextURL) {
+				continue
+			}
+			if len(remoteContexts) >= maxRemoteContexts {
+				return nil, jsonldError("context overflow", nil)
+			}
+			remoteContexts = append(append([]string(nil), remoteContexts...), contextURL)
+
+			loaded, err := c.loadContext(contextURL)
+			if err != nil {
+				return nil, err
+			}
+			u, _ := url.Parse(contextURL)
+			if result, err = result.process(loaded, u, remoteContexts, false, true, validateScoped); err != nil {
+				return nil, err
+			}
+			continue
+		case map[string]interface{}:
+			var err error
+			if result, err = result.processLocal(v, baseURL, remoteContexts, overrideProtected, validateScoped); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, jsonldError("invalid local context", nil)
+		}
+	}
+	return result, nil
+}
+
+// processLocal processes a context definition map into the context.
+func (c *jsonldContext) processLocal(ctx map[string]interface{}, baseURL *url.URL, remoteContexts []string,
+	overrideProtected, validateScoped bool) (*jsonldContext, error) {
+	result := c
+	if v, ok := ctx["@version"]; ok {
+		if f, ok := v.(float64); !ok || f != 1.1 {
+			return nil, jsonldError("invalid @version value", nil)
+		}
+	}
+
+	if v, ok := ctx["@import"]; ok {
+		s, ok := v.(string)
+		if !ok {
+			return nil, jsonldError("invalid @import value", nil)
+		}
+		imported, err := c.loadContext(resolveIRI(baseURL, s))
+		if err != nil {
+			return nil, err
+		}
+		m, ok := imported.(map[string]interface{})
+		if !ok {
+			return nil, jsonldError("invalid remote context", nil)
+		}
+		if _, ok := m["@import"]; ok {
+			return nil, jsonldError("invalid context entry", nil)
+		}
+		merged := make(map[string]interface{}, len(m)+len(ctx))
+		for k, v := range m {
+			merged[k] = v
+		}
+		for k, v := range ctx {
+			merged[k] = v
+		}
+		ctx = merged
+	}
+
+	if v, ok := ctx["@base"]; ok && len(remoteContexts) == 0 {
+		switch b := v.(type) {
+		case nil:
+			result.base = nil
+		case string:
+			if isAbsoluteIRI(b) {
+				u, err := url.Parse(b)
+				if err != nil {
+					return nil, jsonldError("invalid base IRI", err)
+				}
+				result.base = u
+			} else if result.base != nil {
+				u, err := result.base.Parse(b)
+				if err != nil {
+					return nil, jsonldError("invalid base IRI", err)
+				}
+				result.base = u
+			} else {
+				return nil, jsonldError("invalid base IRI", nil)
+			}
+		default:
+			return nil, jsonldError("invalid base IRI", nil)
+		}
+	}
+
+	if v, ok := ctx["@vocab"]; ok {
+		switch vocab := v.(type) {
+		case nil:
+			result.vocab, result.hasVocab = "", false
+		case string:
+			expanded, err := result.expandIRI(vocab, true, true, nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			if !isAbsoluteIRI(expanded) && !strings.HasPrefix(expanded, "_:") {
+				return nil, jsonldError("invalid vocab mapping", nil)
+			}
+			result.vocab, result.hasVocab = expanded, true
+		default:
+			return nil, jsonldError("invalid vocab mapping", nil)
+		}
+	}
+
+	if v, ok := ctx["@language"]; ok {
+		switch lang := v.(type) {
+		case nil:
+			result.language = ""
+		case string:
+			result.language = strings.ToLower(lang)
+		default:
+			return nil, jsonldError("invalid default language", nil)
+		}
+	}
+
+	if v, ok := ctx["@direction"]; ok {
+		switch dir := v.(type) {
+		case nil:
+			result.direction = ""
+		case string:
+			if dir != "ltr" && dir != "rtl" {
+				return nil, jsonldError("invalid base direction", nil)
+			}
+			result.direction = dir
+		default:
+			return nil, jsonldError("invalid base direction", nil)
+		}
+	}
+
+	if v, ok := ctx["@propagate"]; ok {
+		if _, ok := v.(bool); !ok {
+			return nil, jsonldError("invalid @propagate value", nil)
+		}
+	}
+
+	protected, _ := ctx["@protected"].(bool)
+	defined := make(map[string]bool)
+	for _, term := range sortedKeys(ctx) {
+		switch term {
+		case "@base", "@direction", "@import", "@language", "@propagate", "@protected", "@version", "@vocab":
+			continue
+		}
+		if err := result.createTerm(ctx, term, defined, baseURL, protected, overrideProtected, remoteContexts,
+			validateScoped); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// loadContext returns the @context of the document at the given URL.
+func (c *jsonldContext) loadContext(contextURL string) (interface{}, error) {
+	loader := c.loader
+	if loader == nil {
+		loader = DefaultDocumentLoader
+	}
+	doc, err := loader(contextURL)
+	if err != nil {
+		return nil, jsonldError("loading remote context failed", err)
+	}
+	m, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, jsonldError("invalid remote context", nil)
+	}
+	ctx, ok := m["@context"]
+	if !ok {
+		return nil, jsonldError("invalid remote context", nil)
+	}
+	return ctx, nil
+}
+
+// termDefinitionKeys are the entries allowed in an expanded term definition.
+var termDefinitionKeys = map[string]bool{
+	"@id": true, "@reverse": true, "@container": true, "@context": true, "@direction": true, "@index": true,
+	"@language": true, "@nest": true, "@prefix": true, "@protected": true, "@type": true,
+}
+
+// createTerm implements the Create Term Definition algorithm, see https://www.w3.org/TR/json-ld11-api/#create-term-definition.
+func (c *jsonldContext) createTerm(local map[string]interface{}, term string, defined map[string]bool,
+	baseURL *url.URL, protected, overrideProtected bool, remoteContexts []string, validateScoped bool) error {
+	if done, ok := defined[term]; ok {
+		if done {
+			return nil
+		}
+		return jsonldError("cyclic IRI mapping", nil)
+	}
+	if term == "" {
+		return jsonldError("invalid term definition", nil)
+	}
+	defined[term] = false
+	value := local[term]
+
+	if term == "@type" {
+		m, ok := value.(map[string]interface{})
+		if !ok || len(m) == 0 {
+			return jsonldError("keyword redefinition", nil)
+		}
+		for k, v := range m {
+			if (k != "@container" || v != "@set") && k != "@protected" {
+				return jsonldError("keyword redefinition", nil)
+			}
+		}
+	} else if isKeyword(term) {
+		return jsonldError("keyword redefinition", nil)
+	} else if keywordForm.MatchString(term) {
+		// Reserved for future keywords, ignored.
+		return nil
+	}
+
+	previous := c.terms[term]
+	delete(c.terms, term)
+
+	simpleTerm := false
+	var m map[string]interface{}
+	switch v := value.(type) {
+	case nil:
+		m = map[string]interface{}{"@id": nil}
+	case string:
+		m = map[string]interface{}{"@id": v}
+		simpleTerm = true
+	case map[string]interface{}:
+		m = v
+	default:
+		return jsonldError("invalid term definition", nil)
+	}
+
+	def := &termDefinition{protected: protected}
+	if v, ok := m["@protected"]; ok {
+		b, ok := v.(bool)
+		if !ok {
+			return jsonldError("invalid @protected value", nil)
+		}
+		def.protected = b
+	}
+
+	if v, ok := m["@type"]; ok {
+		s, ok := v.(string)
+		if !ok {
+			return jsonldError("invalid type mapping", nil)
+		}
+		typ, err := c.expandIRI(s, false, true, local, defined)
+		if err != nil {
+			return err
+		}
+		if typ != "@id" && typ != "@vocab" && typ != "@json" && typ != "@none" && !isAbsoluteIRI(typ) {
+			return jsonldError("invalid type mapping", nil)
+		}
+		def.typ = typ
+	}
+
+	if v, ok := m["@reverse"]; ok {
+		if _, ok := m["@id"]; ok {
+			return jsonldError("invalid reverse property", nil)
+		}
+		if _, ok := m["@nest"]; ok {
+			return jsonldError("invalid reverse property", nil)
+		}
+		s, ok := v.(string)
+		if !ok {
+			return jsonldError("invalid IRI mapping", nil)
+		}
+		if keywordForm.MatchString(s) {
+			return nil
+		}
+		id, err := c.expandIRI(s, false, true, local, defined)
+		if err != nil {
+			return err
+		}
+		if !isAbsoluteIRI(id) {
+			return jsonldError("invalid IRI mapping", nil)
+		}
+		def.id = id
+		if v, ok := m["@container"]; ok {
+			if v != nil && v != "@set" && v != "@index" {
+				return jsonldError("invalid reverse property", nil)
+			}
+			if v != nil {
+				def.container = []string{v.(string)}
+			}
+		}
+		def.reverse = true
+		c.terms[term] = def
+		defined[term] = true
+		return nil
+	}
+
+	if v, ok := m["@id"]; ok && v != term {
+		switch id := v.(type) {
+		case nil:
+			def.id = ""
+		case string:
+			if !isKeyword(id) && keywordForm.MatchString(id) {
+				return nil
+			}
+			expanded, err := c.expandIRI(id, false, true, local, defined)
+			if err != nil {
+				return err
+			}
+			if !isKeyword(expanded) && !isAbsoluteIRI(expanded) {
+				return jsonldError("invalid IRI mapping", nil)
+			}
+			if expanded == "@context" {
+				return jsonldError("invalid keyword alias", nil)
+			}
+			def.id = expanded
+
+			if strings.Contains(strings.Trim(term, ":"), ":") || strings.Contains(term, "/") {
+				defined[term] = true
+				iri, err := c.expandIRI(term, false, true, local, defined)
+				if err != nil {
+					return err
+				}
+				if iri != expanded {
+					return jsonldError("invalid IRI mapping", nil)
+				}
+			}
+			if !strings.ContainsAny(term, ":/") && simpleTerm &&
+				(strings.ContainsAny(expanded[len(expanded)-1:], ":/?#[]@") || strings.HasPrefix(expanded, "_:")) {
+				def.prefix = true
+			}
+		default:
+			return jsonldError("invalid IRI mapping", nil)
+		}
+	} else if i := strings.Index(term, ":"); i > 0 {
+		prefix, suffix := term[:i], term[i+1:]
+		if _, ok := local[prefix]; ok {
+			if err := c.createTerm(local, prefix, defined, baseURL, protected, overrideProtected, remoteContexts,
+				validateScoped); err != nil {
+				return err
+			}
+		}
+		if d, ok := c.terms[prefix]; ok && d != nil {
+			def.id = d.id + suffix
+		} else {
+			def.id = term
+		}
+	} else if strings.Contains(term, "/") {
+		id, err := c.expandIRI(term, false, true, nil, nil)
+		if err != nil {
+			return err
+		}
+		if !isAbsoluteIRI(id) {
+			return jsonldError("invalid IRI mapping", nil)
+		}
+		def.id = id
+	} else if term == "@type" {
+		def.id = "@type"
+	} else if c.hasVocab {
+		def.id = c.vocab + term
+	} else {
+		return jsonldError("invalid IRI mapping", nil)
+	}
+
+	if v, ok := m["@container"]; ok {
+		container, err := containerMapping(v)
+		if err != nil {
+			return err
+		}
+		def.container = container
+		if containsString(container, "@type") {
+			if def.typ == "" {
+				def.typ = "@id"
+			} else if def.typ != "@id" && def.typ != "@vocab" {
+				return jsonldError("invalid type mapping", nil)
+			}
+		}
+	}
+
+	if v, ok := m["@index"]; ok {
+		s, ok := v.(string)
+		if !ok || !def.hasContainer("@index") {
+			return jsonldError("invalid term definition", nil)
+		}
+		if iri, err := c.expandIRI(s, false, true, local, defined); err != nil || !isAbsoluteIRI(iri) {
+			return jsonldError("invalid term definition", err)
+		}
+		def.index = s
+	}
+
+	if v, ok := m["@context"]; ok {
+		if _, err := c.process(v, baseURL, remoteContexts, true, true, false); err != nil {
+			return jsonldError("invalid scoped context", err)
+		}
+		def.context, def.hasContext, def.baseURL = v, true, baseURL
+	}
+
+	if v, ok := m["@language"]; ok {
+		if _, ok := m["@type"]; !ok {
+			switch lang := v.(type) {
+			case nil:
+				def.language = new(string)
+			case string:
+				lang = strings.ToLower(lang)
+				def.language = &lang
+			default:
+				return jsonldError("invalid language mapping", nil)
+			}
+		}
+	}
+
+	if v, ok := m["@direction"]; ok {
+		if _, ok := m["@type"]; !ok {
+			switch dir := v.(type) {
+			case nil:
+				def.direction = new(string)
+			case string:
+				if dir != "ltr" && dir != "rtl" {
+					return jsonldError("invalid base direction", nil)
+				}
+				def.direction = &dir
+			default:
+				return jsonldError("invalid base direction", nil)
+			}
+		}
+	}
+
+	if v, ok := m["@nest"]; ok {
+		s, ok := v.(string)
+		if !ok || (isKeyword(s) && s != "@nest") {
+			return jsonldError("invalid @nest value", nil)
+		}
+		def.nest = s
+	}
+
+	if v, ok := m["@prefix"]; ok {
+		if strings.ContainsAny(term, ":/") {
+			return jsonldError("invalid term definition", nil)
+		}
+		b, ok := v.(bool)
+		if !ok {
+			return jsonldError("invalid @prefix value", nil)
+		}
+		if b && isKeyword(def.id) {
+			return jsonldError("invalid term definition", nil)
+		}
+		def.prefix = b
+	}
+
+	for k := range m {
+		if !termDefinitionKeys[k] {
+			return jsonldError("invalid term definition", nil)
+		}
+	}
+
+	if !overrideProtected && previous != nil && previous.protected {
+		if !def.equal(previous) {
+			return jsonldError("protected term redefinition", nil)
+		}
+		def = previous
+	}
+
+	c.terms[term] = def
+	defined[term] = true
+	return nil
+}
+
+// containerMapping returns the validated @container value of a term definition.
+func containerMapping(v interface{}) ([]string, error) {
+	var container []string
+	for _, c := range asArray(v) {
+		s, ok := c.(string)
+		if !ok {
+			return nil, jsonldError("invalid container mapping", nil)
+		}
+		switch s {
+		case "@graph", "@id", "@index", "@language", "@list", "@set", "@type":
+		default:
+			return nil, jsonldError("invalid container mapping", nil)
+		}
+		if !containsString(container, s) {
+			container = append(container, s)
+		}
+	}
+	sort.Strings(container)
+
+	valid := len(container) == 1
+	switch {
+	case containsString(container, "@list"):
+	case containsString(container, "@graph"):
+		valid = true
+		for _, s := range container {
+			if s != "@graph" && s != "@id" && s != "@index" && s != "@set" {
+				valid = false
+			}
+		}
+		if containsString(container, "@id") && containsString(container, "@index") {
+			valid = false
+		}
+	case containsString(container, "@set"):
+		valid = len(container) <= 2
+	}
+	if !valid {
+		return nil, jsonldError("invalid container mapping", nil)
+	}
+	return container, nil
+}
+
+// expandIRI implements the IRI Expansion algorithm, see https://www.w3.org/TR/json-ld11-api/#iri-expansion. It
+// returns "" for values mapped to null. When local is set, the terms it defines are created on demand.
+func (c *jsonldContext) expandIRI(value string, documentRelative, vocab bool, local map[string]interface{},
+	defined map[string]bool) (string, error) {
+	if isKeyword(value) {
+		return value, nil
+	}
+	if keywordForm.MatchString(value) {
+		return "", nil
+	}
+
+	if local != nil {
+		if _, ok := local[value]; ok && !defined[value] {
+			if err := c.createTerm(local, value, defined, c.base, false, false, nil, true); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if d, ok := c.terms[value]; ok && (vocab || (d != nil && isKeyword(d.id))) {
+		if d == nil {
+			return "", nil
+		}
+		return d.id, nil
+	}
+
+	if i := strings.Index(value, ":"); i > 0 {
+		prefix, suffix := value[:i], value[i+1:]
+		if prefix == "_" || strings.HasPrefix(suffix, "//") {
+			return value, nil
+		}
+		if local != nil {
+			if _, ok := local[prefix]; ok && !defined[prefix] {
+				if err := c.createTerm(local, prefix, defined, c.base, false, false, nil, true); err != nil {
+					return "", err
+				}
+			}
+		}
+		if d, ok := c.terms[prefix]; ok && d != nil && d.id != "" && d.prefix {
+			return d.id + suffix, nil
+		}
+		if isAbsoluteIRI(value) {
+			return value, nil
+		}
+	}
+
+	if vocab && c.hasVocab {
+		return c.vocab + value, nil
+	}
+	if documentRelative {
+		if c.base == nil {
+			return value, nil
+		}
+		return resolveIRI(c.base, value), nil
+	}
+	return value, nil
+}
+
+// resolveIRI returns the IRI resolved against the base, or as is when it can't be.
+func resolveIRI(base *url.URL, iri string) string {
+	if base == nil || isAbsoluteIRI(iri) {
+		return iri
+	}
+	u, err := base.Parse(iri)
+	if err != nil {
+		return iri
+	}
+	return u.String()
+}
+
+// asArray returns the value as an array, wrapping it unless it is one already.
+func asArray(v interface{}) []interface{} {
+	if a, ok := v.([]interface{}); ok {
+		return a
+	}
+	return []interface{}{v}
+}
+
+// sortedKeys returns the keys of the map in lexicographical order.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// equalStringPtr returns true if both pointers are nil or point to the same string.
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
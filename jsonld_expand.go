@@ -0,0 +1,701 @@
+package microdata
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// expander holds the state of the Expansion algorithm.
+type expander struct {
+	frameExpansion bool
+}
+
+// expand implements the Expansion algorithm, see https://www.w3.org/TR/json-ld11-api/#expansion-algorithm. It
+// returns nil for the elements that are dropped.
+func (e *expander) expand(active *jsonldContext, property string, element interface{}, baseURL *url.URL,
+	fromMap bool) (interface{}, error) {
+	if element == nil {
+		return nil, nil
+	}
+	if property == "@default" {
+		e = &expander{}
+	}
+
+	propertyDef := active.terms[property]
+
+	switch v := element.(type) {
+	case []interface{}:
+		result := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			expanded, err := e.expand(active, property, item, baseURL, fromMap)
+			if err != nil {
+				return nil, err
+			}
+			if propertyDef.hasContainer("@list") {
+				if a, ok := expanded.([]interface{}); ok {
+					expanded = map[string]interface{}{"@list": a}
+				}
+			}
+			if a, ok := expanded.([]interface{}); ok {
+				result = append(result, a...)
+			} else if expanded != nil {
+				result = append(result, expanded)
+			}
+		}
+		return result, nil
+	case map[string]interface{}:
+		return e.expandObject(active, property, v, baseURL, fromMap)
+	default:
+		if property == "" || property == "@graph" {
+			return nil, nil
+		}
+		if propertyDef != nil && propertyDef.hasContext {
+			var err error
+			active, err = active.process(propertyDef.context, propertyDef.baseURL, nil, true, true, true)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return active.expandValue(property, element)
+	}
+}
+
+// expandObject expands a map of the Expansion algorithm.
+func (e *expander) expandObject(active *jsonldContext, property string, element map[string]interface{},
+	baseURL *url.URL, fromMap bool) (interface{}, error) {
+	propertyDef := active.terms[property]
+
+	if active.previous != nil && !fromMap {
+		revert := true
+		keys := sortedKeys(element)
+		for _, k := range keys {
+			iri, err := active.expandIRI(k, false, true, nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			if iri == "@value" || (len(keys) == 1 && iri == "@id") {
+				revert = false
+				break
+			}
+		}
+		if revert {
+			active = active.previous
+		}
+	}
+
+	var err error
+	if propertyDef != nil && propertyDef.hasContext {
+		if active, err = active.process(propertyDef.context, propertyDef.baseURL, nil, true, true, true); err != nil {
+			return nil, err
+		}
+	}
+	if ctx, ok := element["@context"]; ok {
+		if active, err = active.process(ctx, baseURL, nil, false, true, true); err != nil {
+			return nil, err
+		}
+	}
+
+	typeScoped := active
+	var inputType string
+	for _, k := range sortedKeys(element) {
+		iri, err := active.expandIRI(k, false, true, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		if iri != "@type" {
+			continue
+		}
+
+		var terms []string
+		for _, t := range asArray(element[k]) {
+			if s, ok := t.(string); ok {
+				terms = append(terms, s)
+			}
+		}
+		sort.Strings(terms)
+		for _, t := range terms {
+			if d := typeScoped.terms[t]; d != nil && d.hasContext {
+				if active, err = active.process(d.context, d.baseURL, nil, false, false, true); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if len(terms) > 0 {
+			last := asArray(element[k])
+			if s, ok := last[len(last)-1].(string); ok {
+				if inputType, err = active.expandIRI(s, false, true, nil, nil); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	result := make(map[string]interface{})
+	if err := e.expandEntries(active, typeScoped, property, element, result, baseURL, inputType); err != nil {
+		return nil, err
+	}
+
+	if value, ok := result["@value"]; ok {
+		for k := range result {
+			switch k {
+			case "@direction", "@index", "@language", "@type", "@value":
+			default:
+				return nil, jsonldError("invalid value object", nil)
+			}
+		}
+		_, hasLanguage := result["@language"]
+		_, hasDirection := result["@direction"]
+		typ, hasType := result["@type"]
+		if hasType && (hasLanguage || hasDirection) {
+			return nil, jsonldError("invalid value object", nil)
+		}
+		if typ == "@json" {
+			return result, nil
+		}
+		if value == nil {
+			return nil, nil
+		}
+		if _, ok := value.(string); !ok && hasLanguage {
+			return nil, jsonldError("invalid language-tagged value", nil)
+		}
+		if hasType {
+			if s, ok := typ.(string); !ok || !isAbsoluteIRI(s) || strings.HasPrefix(s, "_:") {
+				if !e.frameExpansion {
+					return nil, jsonldError("invalid typed value", nil)
+				}
+			}
+		}
+	} else if typ, ok := result["@type"]; ok {
+		if _, ok := typ.([]interface{}); !ok {
+			result["@type"] = []interface{}{typ}
+		}
+	} else if _, ok := result["@set"]; ok {
+		if err := checkSetOrList(result); err != nil {
+			return nil, err
+		}
+		return result["@set"], nil
+	} else if _, ok := result["@list"]; ok {
+		if err := checkSetOrList(result); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := result["@language"]; ok && len(result) == 1 {
+		return nil, nil
+	}
+
+	if property == "" || property == "@graph" {
+		_, hasValue := result["@value"]
+		_, hasList := result["@list"]
+		_, hasID := result["@id"]
+		if len(result) == 0 || hasValue || hasList {
+			return nil, nil
+		}
+		if len(result) == 1 && hasID && !e.frameExpansion {
+			return nil, nil
+		}
+	}
+	return result, nil
+}
+
+// checkSetOrList returns an error if the @set or @list object has other entries than @index.
+func checkSetOrList(result map[string]interface{}) error {
+	for k := range result {
+		if k != "@set" && k != "@list" && k != "@index" {
+			return jsonldError("invalid set or list object", nil)
+		}
+	}
+	return nil
+}
+
+// expandEntries expands the entries of the element into the result.
+func (e *expander) expandEntries(active, typeScoped *jsonldContext, property string, element,
+	result map[string]interface{}, baseURL *url.URL, inputType string) error {
+	var nests []string
+
+	for _, key := range sortedKeys(element) {
+		value := element[key]
+		if key == "@context" {
+			continue
+		}
+		expandedProperty, err := active.expandIRI(key, false, true, nil, nil)
+		if err != nil {
+			return err
+		}
+		if expandedProperty == "" || (!strings.Contains(expandedProperty, ":") && !isKeyword(expandedProperty)) {
+			continue
+		}
+
+		if isKeyword(expandedProperty) {
+			if property == "@reverse" {
+				return jsonldError("invalid reverse property map", nil)
+			}
+			if _, ok := result[expandedProperty]; ok && expandedProperty != "@included" && expandedProperty != "@type" {
+				return jsonldError("colliding keywords", nil)
+			}
+
+			var expandedValue interface{}
+			switch expandedProperty {
+			case "@id":
+				s, ok := value.(string)
+				if !ok {
+					if !e.frameExpansion {
+						return jsonldError("invalid @id value", nil)
+					}
+					expandedValue = frameIDs(active, value)
+					break
+				}
+				if expandedValue, err = active.expandIRI(s, true, false, nil, nil); err != nil {
+					return err
+				}
+			case "@type":
+				if m, ok := value.(map[string]interface{}); ok && e.frameExpansion {
+					if len(m) != 0 {
+						return jsonldError("invalid type value", nil)
+					}
+					result["@type"] = m
+					continue
+				}
+				var types []interface{}
+				for _, t := range asArray(value) {
+					s, ok := t.(string)
+					if !ok {
+						return jsonldError("invalid type value", nil)
+					}
+					iri, err := typeScoped.expandIRI(s, true, true, nil, nil)
+					if err != nil {
+						return err
+					}
+					types = append(types, iri)
+				}
+				if existing, ok := result["@type"]; ok {
+					types = append(asArray(existing), types...)
+				}
+				if _, ok := value.([]interface{}); ok || len(types) > 1 {
+					expandedValue = types
+				} else if len(types) == 1 {
+					expandedValue = types[0]
+				} else {
+					expandedValue = []interface{}{}
+				}
+			case "@graph":
+				expanded, err := e.expand(active, "@graph", value, baseURL, false)
+				if err != nil {
+					return err
+				}
+				expandedValue = asArrayOrEmpty(expanded)
+			case "@included":
+				expanded, err := e.expand(active, "", value, baseURL, false)
+				if err != nil {
+					return err
+				}
+				included := asArrayOrEmpty(expanded)
+				for _, n := range included {
+					if !isNodeObject(n) {
+						return jsonldError("invalid @included value", nil)
+					}
+				}
+				if existing, ok := result["@included"]; ok {
+					included = append(asArray(existing), included...)
+				}
+				expandedValue = included
+			case "@value":
+				if inputType == "@json" {
+					result["@value"] = value
+					continue
+				}
+				switch value.(type) {
+				case nil:
+					result["@value"] = nil
+					continue
+				case string, bool, float64, float32, int, int64, json.Number:
+				default:
+					if !e.frameExpansion {
+						return jsonldError("invalid value object value", nil)
+					}
+				}
+				expandedValue = value
+			case "@language":
+				s, ok := value.(string)
+				if !ok {
+					if !e.frameExpansion {
+						return jsonldError("invalid language-tagged string", nil)
+					}
+					expandedValue = value
+					break
+				}
+				expandedValue = strings.ToLower(s)
+			case "@direction":
+				if value != "ltr" && value != "rtl" {
+					return jsonldError("invalid base direction", nil)
+				}
+				expandedValue = value
+			case "@index":
+				if _, ok := value.(string); !ok {
+					return jsonldError("invalid @index value", nil)
+				}
+				expandedValue = value
+			case "@list":
+				if property == "" || property == "@graph" {
+					continue
+				}
+				expanded, err := e.expand(active, property, value, baseURL, false)
+				if err != nil {
+					return err
+				}
+				expandedValue = asArrayOrEmpty(expanded)
+			case "@set":
+				if expandedValue, err = e.expand(active, property, value, baseURL, false); err != nil {
+					return err
+				}
+			case "@reverse":
+				if _, ok := value.(map[string]interface{}); !ok {
+					return jsonldError("invalid @reverse value", nil)
+				}
+				expanded, err := e.expand(active, "@reverse", value, baseURL, false)
+				if err != nil {
+					return err
+				}
+				m, _ := expanded.(map[string]interface{})
+				if reverse, ok := m["@reverse"].(map[string]interface{}); ok {
+					for p, items := range reverse {
+						addValue(result, p, items)
+					}
+				}
+				for p, items := range m {
+					if p == "@reverse" {
+						continue
+					}
+					reverseMap, _ := result["@reverse"].(map[string]interface{})
+					if reverseMap == nil {
+						reverseMap = make(map[string]interface{})
+						result["@reverse"] = reverseMap
+					}
+					for _, item := range asArray(items) {
+						if isValueObject(item) || isListObject(item) {
+							return jsonldError("invalid reverse property value", nil)
+						}
+						addValue(reverseMap, p, item)
+					}
+				}
+				continue
+			case "@nest":
+				nests = append(nests, key)
+				continue
+			case "@default", "@embed", "@explicit", "@omitDefault", "@requireAll":
+				if !e.frameExpansion {
+					continue
+				}
+				if expandedValue, err = e.expand(active, property, value, baseURL, false); err != nil {
+					return err
+				}
+				if expandedValue == nil {
+					expandedValue = value
+				}
+			default:
+				continue
+			}
+
+			if expandedValue != nil || expandedProperty == "@value" {
+				result[expandedProperty] = expandedValue
+			}
+			continue
+		}
+
+		def := active.terms[key]
+		var expandedValue interface{}
+		switch m, isMap := value.(map[string]interface{}); {
+		case def != nil && def.typ == "@json":
+			expandedValue = map[string]interface{}{"@value": value, "@type": "@json"}
+		case isMap && def.hasContainer("@language"):
+			expandedValue, err = active.expandLanguageMap(def, m)
+		case isMap && (def.hasContainer("@index") || def.hasContainer("@type") || def.hasContainer("@id")):
+			expandedValue, err = e.expandIndexMap(active, key, def, m, baseURL)
+		default:
+			expandedValue, err = e.expand(active, key, value, baseURL, false)
+		}
+		if err != nil {
+			return err
+		}
+		if expandedValue == nil {
+			continue
+		}
+
+		if def.hasContainer("@list") && !isListObject(expandedValue) {
+			expandedValue = map[string]interface{}{"@list": asArrayOrEmpty(expandedValue)}
+		}
+		if def.hasContainer("@graph") && !def.hasContainer("@id") && !def.hasContainer("@index") {
+			var graphs []interface{}
+			for _, ev := range asArray(expandedValue) {
+				graphs = append(graphs, map[string]interface{}{"@graph": asArray(ev)})
+			}
+			expandedValue = graphs
+		}
+
+		if def != nil && def.reverse {
+			reverseMap, _ := result["@reverse"].(map[string]interface{})
+			if reverseMap == nil {
+				reverseMap = make(map[string]interface{})
+				result["@reverse"] = reverseMap
+			}
+			for _, item := range asArray(expandedValue) {
+				if isValueObject(item) || isListObject(item) {
+					return jsonldError("invalid reverse property value", nil)
+				}
+				addValue(reverseMap, expandedProperty, item)
+			}
+			continue
+		}
+		addValue(result, expandedProperty, expandedValue)
+	}
+
+	for _, key := range nests {
+		for _, nested := range asArray(element[key]) {
+			m, ok := nested.(map[string]interface{})
+			if !ok {
+				return jsonldError("invalid @nest value", nil)
+			}
+			for k := range m {
+				if iri, _ := active.expandIRI(k, false, true, nil, nil); iri == "@value" {
+					return jsonldError("invalid @nest value", nil)
+				}
+			}
+			if err := e.expandEntries(active, typeScoped, property, m, result, baseURL, inputType); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// expandLanguageMap expands the value of a term with a @language container.
+func (c *jsonldContext) expandLanguageMap(def *termDefinition, m map[string]interface{}) (interface{}, error) {
+	direction := c.direction
+	if def.direction != nil {
+		direction = *def.direction
+	}
+
+	result := []interface{}{}
+	for _, lang := range sortedKeys(m) {
+		expandedLang, err := c.expandIRI(lang, false, true, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range asArray(m[lang]) {
+			if item == nil {
+				continue
+			}
+			s, ok := item.(string)
+			if !ok {
+				return nil, jsonldError("invalid language map value", nil)
+			}
+			v := map[string]interface{}{"@value": s}
+			if expandedLang != "@none" {
+				v["@language"] = strings.ToLower(lang)
+			}
+			if direction != "" {
+				v["@direction"] = direction
+			}
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+// expandIndexMap expands the value of a term with an @index, @id or @type container.
+func (e *expander) expandIndexMap(active *jsonldContext, key string, def *termDefinition, m map[string]interface{},
+	baseURL *url.URL) (interface{}, error) {
+	indexKey := def.index
+	if indexKey == "" {
+		indexKey = "@index"
+	}
+
+	result := []interface{}{}
+	for _, index := range sortedKeys(m) {
+		mapContext := active
+		if def.hasContainer("@id") || def.hasContainer("@type") {
+			if active.previous != nil {
+				mapContext = active.previous
+			}
+		}
+		if d := mapContext.terms[index]; def.hasContainer("@type") && d != nil && d.hasContext {
+			var err error
+			if mapContext, err = mapContext.process(d.context, d.baseURL, nil, false, true, true); err != nil {
+				return nil, err
+			}
+		} else if !def.hasContainer("@type") {
+			mapContext = active
+		}
+
+		expandedIndex, err := active.expandIRI(index, false, true, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		expanded, err := e.expand(mapContext, key, asArray(m[index]), baseURL, true)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range asArrayOrEmpty(expanded) {
+			if def.hasContainer("@graph") && !isGraphObject(item) {
+				item = map[string]interface{}{"@graph": asArray(item)}
+			}
+			obj, _ := item.(map[string]interface{})
+			if obj == nil {
+				continue
+			}
+
+			switch {
+			case def.hasContainer("@index") && indexKey != "@index" && expandedIndex != "@none":
+				reexpanded, err := active.expandValue(indexKey, index)
+				if err != nil {
+					return nil, err
+				}
+				expandedIndexKey, err := active.expandIRI(indexKey, false, true, nil, nil)
+				if err != nil {
+					return nil, err
+				}
+				values := []interface{}{reexpanded}
+				if existing, ok := obj[expandedIndexKey]; ok {
+					values = append(values, asArray(existing)...)
+				}
+				obj[expandedIndexKey] = values
+				if isValueObject(obj) && len(obj) > 1 {
+					return nil, jsonldError("invalid value object", nil)
+				}
+			case def.hasContainer("@index") && expandedIndex != "@none":
+				if _, ok := obj["@index"]; !ok {
+					obj["@index"] = index
+				}
+			case def.hasContainer("@id") && expandedIndex != "@none":
+				if _, ok := obj["@id"]; !ok {
+					if obj["@id"], err = active.expandIRI(index, true, false, nil, nil); err != nil {
+						return nil, err
+					}
+				}
+			case def.hasContainer("@type") && expandedIndex != "@none":
+				types := []interface{}{expandedIndex}
+				if existing, ok := obj["@type"]; ok {
+					types = append(types, asArray(existing)...)
+				}
+				obj["@type"] = types
+			}
+			result = append(result, obj)
+		}
+	}
+	return result, nil
+}
+
+// expandValue implements the Value Expansion algorithm, see https://www.w3.org/TR/json-ld11-api/#value-expansion.
+func (c *jsonldContext) expandValue(property string, value interface{}) (interface{}, error) {
+	def := c.terms[property]
+	if s, ok := value.(string); ok && def != nil && (def.typ == "@id" || def.typ == "@vocab") {
+		iri, err := c.expandIRI(s, true, def.typ == "@vocab", nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"@id": iri}, nil
+	}
+
+	result := map[string]interface{}{"@value": value}
+	if def != nil && def.typ != "" && def.typ != "@id" && def.typ != "@vocab" && def.typ != "@none" {
+		result["@type"] = def.typ
+	} else if _, ok := value.(string); ok {
+		language, direction := c.language, c.direction
+		if def != nil && def.language != nil {
+			language = *def.language
+		}
+		if def != nil && def.direction != nil {
+			direction = *def.direction
+		}
+		if language != "" {
+			result["@language"] = language
+		}
+		if direction != "" {
+			result["@direction"] = direction
+		}
+	}
+	return result, nil
+}
+
+// frameIDs expands the @id of a frame, which may be an empty map or an array of IRIs.
+func frameIDs(active *jsonldContext, value interface{}) interface{} {
+	a, ok := value.([]interface{})
+	if !ok {
+		return value
+	}
+	ids := make([]interface{}, 0, len(a))
+	for _, v := range a {
+		if s, ok := v.(string); ok {
+			iri, _ := active.expandIRI(s, true, false, nil, nil)
+			ids = append(ids, iri)
+		}
+	}
+	return ids
+}
+
+// addValue appends the value to the entry of the map, making it an array.
+func addValue(m map[string]interface{}, key string, value interface{}) {
+	values := asArrayOrEmpty(m[key])
+	if a, ok := value.([]interface{}); ok {
+		values = append(values, a...)
+	} else {
+		values = append(values, value)
+	}
+	m[key] = values
+}
+
+// asArrayOrEmpty returns the value as an array, an empty one when it is nil.
+func asArrayOrEmpty(v interface{}) []interface{} {
+	if v == nil {
+		return []interface{}{}
+	}
+	return asArray(v)
+}
+
+// isValueObject returns true if v is a map with a @value entry.
+func isValueObject(v interface{}) bool {
+	m, ok := v.(map[string]interface{})
+	_, has := m["@value"]
+	return ok && has
+}
+
+// isListObject returns true if v is a map with a @list entry.
+func isListObject(v interface{}) bool {
+	m, ok := v.(map[string]interface{})
+	_, has := m["@list"]
+	return ok && has
+}
+
+// isGraphObject returns true if v is a map with a @graph entry and no other entries than @id and @index.
+func isGraphObject(v interface{}) bool {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	if _, ok := m["@graph"]; !ok {
+		return false
+	}
+	for k := range m {
+		if k != "@graph" && k != "@id" && k != "@index" && k != "@context" {
+			return false
+		}
+	}
+	return true
+}
+
+// isNodeObject returns true if v is a map that isn't a value, list or set object.
+func isNodeObject(v interface{}) bool {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for _, k := range []string{"@value", "@list", "@set"} {
+		if _, ok := m[k]; ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,490 @@
+package microdata
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// Frame frames the items of the microdata, see FrameJSONLD, e.g. to get the products on a page with their offers
+// and brand embedded whether they were nested or linked with @id:
+//
+//	framed, err := Frame(data, map[string]interface{}{
+//		"@context": "https://schema.org",
+//		"@type":    "Product",
+//		"offers":   map[string]interface{}{"@type": "Offer"},
+//		"brand":    map[string]interface{}{},
+//	})
+func Frame(data *Microdata, frame map[string]interface{}, opts ...JSONLDOption) (map[string]interface{}, error) {
+	expanded, err := data.Expand(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return FrameJSONLD(expanded, frame, append([]JSONLDOption{WithBaseIRI(data.BaseURL)}, opts...)...)
+}
+
+// FrameJSONLD implements the JSON-LD 1.1 Framing algorithm, see https://www.w3.org/TR/json-ld11-framing/. The
+// nodes of the document, in any form, are matched against the frame by @type, @id and properties, the values of
+// their properties are filtered and the nodes they reference are embedded as directed by the frame. @embed
+// (@once, the default, @always or @never), @explicit, @omitDefault, @requireAll and @default are supported;
+// named graphs are merged into the default graph, and @reverse in frames is not supported.
+//
+// The result is compacted with the @context of the frame, with the matching nodes in a @graph entry unless there
+// is only one. Errors are of type *JSONLDError.
+func FrameJSONLD(doc interface{}, frame map[string]interface{}, opts ...JSONLDOption) (map[string]interface{}, error) {
+	expanded, err := Expand(doc, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newJSONLDOptions(opts)
+	active, err := o.initialContext()
+	if err != nil {
+		return nil, err
+	}
+	expandedFrame, err := (&expander{frameExpansion: true}).expand(active, "", frame, active.base, false)
+	if err != nil {
+		return nil, err
+	}
+	frames := asArrayOrEmpty(expandedFrame)
+	if len(frames) > 1 {
+		return nil, jsonldError("invalid frame", errors.New("frame must be a single object"))
+	}
+	topFrame := map[string]interface{}{}
+	if len(frames) == 1 {
+		if topFrame, _ = frames[0].(map[string]interface{}); topFrame == nil {
+			return nil, jsonldError("invalid frame", nil)
+		}
+	}
+
+	nodes := nodeMap{"@default": {}}
+	if err := nodes.generate(expanded, newBlankNodeIssuer("_:b"), "@default", nil, "", nil); err != nil {
+		return nil, err
+	}
+	state := &framingState{
+		subjects:   nodes.merged(),
+		blankNodes: make(map[string][]map[string]interface{}),
+	}
+	framed := []interface{}{}
+	err = state.frame(sortedNodeIDs(state.subjects), topFrame, func(v interface{}) {
+		framed = append(framed, v)
+	}, true)
+	if err != nil {
+		return nil, err
+	}
+	state.pruneBlankNodes()
+
+	context := frame["@context"]
+	active, context, err = compactionContext(context, opts)
+	if err != nil {
+		return nil, err
+	}
+	compacted, err := compact(active, "", framed, true)
+	if err != nil {
+		return nil, err
+	}
+
+	result, ok := compacted.(map[string]interface{})
+	if !ok {
+		result = map[string]interface{}{active.compactIRI("@graph", nil, true, false): asArrayOrEmpty(compacted)}
+	}
+	result, _ = cleanupPreserve(result).(map[string]interface{})
+	if !isEmptyContext(context) {
+		result["@context"] = context
+	}
+	return result, nil
+}
+
+// framingState is the state of the Framing algorithm.
+type framingState struct {
+	// subjects maps the @id of the nodes of the merged graph to the nodes.
+	subjects map[string]map[string]interface{}
+	// embedded holds the @id of the nodes embedded under the current top-level node, for @embed @once.
+	embedded map[string]bool
+	// stack holds the @id of the nodes being framed, to detect cycles.
+	stack []string
+	// blankNodes maps the blank node identifiers to the framed nodes that have them.
+	blankNodes map[string][]map[string]interface{}
+}
+
+// frameFlags are the flags of a frame that the frames nested in it inherit.
+type frameFlags struct {
+	embed      string
+	explicit   bool
+	requireAll bool
+}
+
+// newFrameFlags returns the flags of the frame, the defaults when it doesn't have them.
+func newFrameFlags(frame map[string]interface{}) (frameFlags, error) {
+	flags := frameFlags{embed: "@once"}
+	switch v := frameFlag(frame, "@embed").(type) {
+	case nil:
+	case bool:
+		if !v {
+			flags.embed = "@never"
+		}
+	case string:
+		if v != "@always" && v != "@once" && v != "@never" {
+			return flags, jsonldError("invalid @embed value", errors.New(v))
+		}
+		flags.embed = v
+	default:
+		return flags, jsonldError("invalid @embed value", nil)
+	}
+	flags.explicit, _ = frameFlag(frame, "@explicit").(bool)
+	flags.requireAll, _ = frameFlag(frame, "@requireAll").(bool)
+	return flags, nil
+}
+
+// frameFlag returns the value of the flag of the frame, nil when it doesn't have it. The value may have been
+// expanded to an array or a value object.
+func frameFlag(frame map[string]interface{}, flag string) interface{} {
+	v, ok := frame[flag]
+	if !ok {
+		return nil
+	}
+	if a, ok := v.([]interface{}); ok {
+		if len(a) == 0 {
+			return nil
+		}
+		v = a[0]
+	}
+	if m, ok := v.(map[string]interface{}); ok && isValueObject(m) {
+		v = m["@value"]
+	}
+	return v
+}
+
+// frame implements the Framing algorithm, see https://www.w3.org/TR/json-ld11-framing/#framing-algorithm: it adds
+// the nodes of the given ids that match the frame, framed, with add.
+func (s *framingState) frame(ids []string, frame map[string]interface{}, add func(interface{}), topLevel bool) error {
+	flags, err := newFrameFlags(frame)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		subject, ok := s.subjects[id]
+		if !ok || !s.matches(subject, frame, flags) {
+			continue
+		}
+		if topLevel {
+			s.embedded = make(map[string]bool)
+		}
+
+		output := map[string]interface{}{"@id": id}
+		if strings.HasPrefix(id, "_:") {
+			s.blankNodes[id] = append(s.blankNodes[id], output)
+		}
+		if flags.embed == "@never" || s.onStack(id) || (flags.embed == "@once" && s.embedded[id]) {
+			add(output)
+			continue
+		}
+		s.embedded[id] = true
+		s.stack = append(s.stack, id)
+
+		for _, property := range sortedKeys(subject) {
+			values := subject[property]
+			if isKeyword(property) {
+				output[property] = values
+				continue
+			}
+			if _, ok := frame[property]; flags.explicit && !ok {
+				continue
+			}
+
+			subframe := s.subframe(frame, property, flags)
+			for _, v := range asArray(values) {
+				value, _ := v.(map[string]interface{})
+				switch {
+				case isListObject(value):
+					listFrame := subframe
+					if l, ok := subframe["@list"]; ok {
+						if m := firstMap(l); m != nil {
+							listFrame = m
+						}
+					}
+					list := map[string]interface{}{"@list": []interface{}{}}
+					addValue(output, property, list)
+					for _, item := range asArray(value["@list"]) {
+						if ref, ok := nodeReference(item); ok {
+							err := s.frame([]string{ref}, listFrame, func(v interface{}) {
+								list["@list"] = append(list["@list"].([]interface{}), v)
+							}, false)
+							if err != nil {
+								return err
+							}
+						} else {
+							list["@list"] = append(list["@list"].([]interface{}), item)
+						}
+					}
+				case isNodeReferenceMap(value):
+					err := s.frame([]string{value["@id"].(string)}, subframe, func(v interface{}) {
+						addValue(output, property, v)
+					}, false)
+					if err != nil {
+						return err
+					}
+				case valueMatches(subframe, value):
+					addValue(output, property, value)
+				}
+			}
+		}
+
+		for _, property := range sortedKeys(frame) {
+			if isKeyword(property) {
+				continue
+			}
+			next := firstMap(frame[property])
+			if omitDefault, _ := frameFlag(next, "@omitDefault").(bool); omitDefault {
+				continue
+			}
+			if _, ok := output[property]; !ok {
+				var preserve interface{} = "@null"
+				if d, ok := next["@default"]; ok {
+					preserve = d
+				}
+				output[property] = []interface{}{map[string]interface{}{"@preserve": asArray(preserve)}}
+			}
+		}
+
+		add(output)
+		s.stack = s.stack[:len(s.stack)-1]
+	}
+	return nil
+}
+
+// subframe returns the frame of the values of the property, an implicit frame with the flags of the frame when
+// it doesn't have the property.
+func (s *framingState) subframe(frame map[string]interface{}, property string,
+	flags frameFlags) map[string]interface{} {
+	if values, ok := frame[property]; ok {
+		if m := firstMap(values); m != nil {
+			return m
+		}
+		return map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"@embed":      flags.embed,
+		"@explicit":   flags.explicit,
+		"@requireAll": flags.requireAll,
+	}
+}
+
+// onStack returns true if the node is being framed, embedding it would create a cycle.
+func (s *framingState) onStack(id string) bool {
+	for _, e := range s.stack {
+		if e == id {
+			return true
+		}
+	}
+	return false
+}
+
+// matches returns true if the node matches the frame, see
+// https://www.w3.org/TR/json-ld11-framing/#frame-matching-algorithm.
+func (s *framingState) matches(subject, frame map[string]interface{}, flags frameFlags) bool {
+	wildcard := true
+	matchesSome := false
+	for _, key := range sortedKeys(frame) {
+		matchThis := false
+		nodeValues := asArrayOrEmpty(subject[key])
+		frameValues := asArrayOrEmpty(frame[key])
+		isEmpty := len(frameValues) == 0
+
+		switch {
+		case key == "@id":
+			if len(frameValues) == 0 || isEmptyMap(frameValues[0]) {
+				matchThis = true
+			} else {
+				matchThis = containsDeepEqual(frameValues, subject["@id"])
+			}
+			if !flags.requireAll {
+				return matchThis
+			}
+		case key == "@type":
+			wildcard = false
+			switch {
+			case isEmpty:
+				if len(nodeValues) > 0 {
+					return false
+				}
+				matchThis = true
+			case len(frameValues) == 1 && isEmptyMap(frameValues[0]):
+				matchThis = len(nodeValues) > 0
+			default:
+				for _, t := range frameValues {
+					matchThis = matchThis || containsDeepEqual(nodeValues, t)
+				}
+				if !flags.requireAll {
+					return matchThis
+				}
+			}
+		case isKeyword(key):
+			continue
+		default:
+			thisFrame := firstMap(frameValues)
+			_, hasDefault := thisFrame["@default"]
+			wildcard = false
+			if len(nodeValues) == 0 && hasDefault {
+				continue
+			}
+			if len(nodeValues) > 0 && isEmpty {
+				return false
+			}
+
+			switch {
+			case thisFrame == nil:
+				if len(nodeValues) > 0 {
+					return false
+				}
+				matchThis = true
+			case isListObject(thisFrame):
+				listValue := firstMap(thisFrame["@list"])
+				if list, ok := firstMap(nodeValues)["@list"]; ok && listValue != nil {
+					for _, v := range asArray(list) {
+						if isValueObject(listValue) {
+							matchThis = matchThis || valueMatches(listValue, v)
+						} else {
+							matchThis = matchThis || s.nodeMatches(listValue, v, flags)
+						}
+					}
+				}
+			case isValueObject(thisFrame):
+				for _, v := range nodeValues {
+					matchThis = matchThis || valueMatches(thisFrame, v)
+				}
+			case isNodeReferenceMap(thisFrame):
+				for _, v := range nodeValues {
+					matchThis = matchThis || s.nodeMatches(thisFrame, v, flags)
+				}
+			default:
+				matchThis = len(nodeValues) > 0
+			}
+		}
+
+		if !matchThis && flags.requireAll {
+			return false
+		}
+		matchesSome = matchesSome || matchThis
+	}
+	return wildcard || matchesSome
+}
+
+// nodeMatches returns true if the value is a reference to a node that matches the frame.
+func (s *framingState) nodeMatches(frame map[string]interface{}, value interface{}, flags frameFlags) bool {
+	id, ok := nodeReference(value)
+	if !ok {
+		return false
+	}
+	node, ok := s.subjects[id]
+	return ok && s.matches(node, frame, flags)
+}
+
+// pruneBlankNodes removes the @id of the framed blank nodes that occur once, see
+// https://www.w3.org/TR/json-ld11-framing/#dom-jsonldoptions-pruneblanknodeidentifiers.
+func (s *framingState) pruneBlankNodes() {
+	for _, outputs := range s.blankNodes {
+		if len(outputs) == 1 {
+			delete(outputs[0], "@id")
+		}
+	}
+}
+
+// valueMatches returns true if the value object matches the value pattern, see
+// https://www.w3.org/TR/json-ld11-framing/#value-pattern-matching-algorithm. A frame without @value, @type or
+// @language matches any value, and a pattern without @value, e.g. {"@type": "http://schema.org/Date"}, matches
+// the values of any lexical form.
+func valueMatches(pattern map[string]interface{}, value interface{}) bool {
+	v, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	v2, t2, l2 := asArrayOrEmpty(pattern["@value"]), asArrayOrEmpty(pattern["@type"]), asArrayOrEmpty(pattern["@language"])
+	if len(v2) == 0 && len(t2) == 0 && len(l2) == 0 {
+		return true
+	}
+	return (len(v2) == 0 || patternMatches(v2, v["@value"])) && patternMatches(t2, v["@type"]) &&
+		patternMatches(l2, v["@language"])
+}
+
+// patternMatches returns true if the entry of a value object matches the entries of a value pattern: it is one
+// of them, or the pattern is a wildcard, {}, and the value has the entry. An entry the value doesn't have only
+// matches an empty pattern.
+func patternMatches(pattern []interface{}, v interface{}) bool {
+	if v == nil {
+		return len(pattern) == 0
+	}
+	return containsDeepEqual(pattern, v) || (len(pattern) > 0 && isEmptyMap(pattern[0]))
+}
+
+// cleanupPreserve replaces the @preserve entries of the framed and compacted document with their values, and the
+// "@null" defaults with null.
+func cleanupPreserve(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		result := make([]interface{}, 0, len(v))
+		for _, e := range v {
+			if e = cleanupPreserve(e); e != nil {
+				result = append(result, e)
+			}
+		}
+		return result
+	case map[string]interface{}:
+		if preserve, ok := v["@preserve"]; ok {
+			return cleanupPreserve(preserve)
+		}
+		for key, e := range v {
+			v[key] = cleanupPreserve(e)
+		}
+		return v
+	case string:
+		if v == "@null" {
+			return nil
+		}
+	}
+	return v
+}
+
+// nodeReference returns the @id of the value if it is a node reference, a map with an @id only.
+func nodeReference(v interface{}) (string, bool) {
+	m, _ := v.(map[string]interface{})
+	if !isNodeReferenceMap(m) {
+		return "", false
+	}
+	return m["@id"].(string), true
+}
+
+// isNodeReferenceMap returns true if the map has a string @id only.
+func isNodeReferenceMap(m map[string]interface{}) bool {
+	_, ok := m["@id"].(string)
+	return ok && len(m) == 1
+}
+
+// firstMap returns the value, or its first element if it is an array, if it is a map, nil otherwise.
+func firstMap(v interface{}) map[string]interface{} {
+	if a, ok := v.([]interface{}); ok {
+		if len(a) == 0 {
+			return nil
+		}
+		v = a[0]
+	}
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+// isEmptyMap returns true if v is a map without entries, a wildcard in frames.
+func isEmptyMap(v interface{}) bool {
+	m, ok := v.(map[string]interface{})
+	return ok && len(m) == 0
+}
+
+// containsDeepEqual returns true if the values contain one deeply equal to v.
+func containsDeepEqual(values []interface{}, v interface{}) bool {
+	for _, e := range values {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
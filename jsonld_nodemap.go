@@ -0,0 +1,261 @@
+package microdata
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// nodeMap maps graph names, "@default" for the default graph, to the nodes of the graphs by @id.
+type nodeMap map[string]map[string]map[string]interface{}
+
+// blankNodeIssuer issues blank node identifiers with a prefix and a counter, e.g. "_:b0", and maps the existing
+// identifiers to the ones issued for them.
+type blankNodeIssuer struct {
+	prefix  string
+	counter int
+	issued  map[string]string
+	// order holds the existing identifiers in the order the identifiers were issued for them.
+	order []string
+}
+
+// newBlankNodeIssuer returns an issuer of identifiers with the given prefix.
+func newBlankNodeIssuer(prefix string) *blankNodeIssuer {
+	return &blankNodeIssuer{prefix: prefix, issued: make(map[string]string)}
+}
+
+// issue returns the identifier issued for the given one, issuing it if needed. A new identifier is issued for "".
+func (b *blankNodeIssuer) issue(id string) string {
+	if issued, ok := b.issued[id]; ok && id != "" {
+		return issued
+	}
+	issued := fmt.Sprintf("%s%d", b.prefix, b.counter)
+	b.counter++
+	if id != "" {
+		b.issued[id] = issued
+		b.order = append(b.order, id)
+	}
+	return issued
+}
+
+// copy returns an issuer with the same state.
+func (b *blankNodeIssuer) copy() *blankNodeIssuer {
+	c := &blankNodeIssuer{prefix: b.prefix, counter: b.counter, issued: make(map[string]string, len(b.issued))}
+	for k, v := range b.issued {
+		c.issued[k] = v
+	}
+	c.order = append(c.order, b.order...)
+	return c
+}
+
+// generate implements the Node Map Generation algorithm, see
+// https://www.w3.org/TR/json-ld11-api/#node-map-generation, over the expanded value. Blank nodes are relabeled
+// with the issuer, including the ones that have no @id. The active subject is an @id, or a node reference when
+// the element is a @reverse value.
+func (nodes nodeMap) generate(value interface{}, issuer *blankNodeIssuer, activeGraph string,
+	activeSubject interface{}, activeProperty string, list map[string]interface{}) error {
+	if a, ok := value.([]interface{}); ok {
+		for _, item := range a {
+			if err := nodes.generate(item, issuer, activeGraph, activeSubject, activeProperty, list); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	element, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	graph := nodes.graph(activeGraph)
+	var subjectNode map[string]interface{}
+	if s, ok := activeSubject.(string); ok {
+		subjectNode = graph[s]
+	}
+
+	// The @type of value objects is their datatype, which isn't relabeled.
+	if types, ok := element["@type"]; ok && !isValueObject(element) {
+		relabeled := make([]interface{}, 0, len(asArray(types)))
+		for _, t := range asArray(types) {
+			if s, ok := t.(string); ok && strings.HasPrefix(s, "_:") {
+				t = issuer.issue(s)
+			}
+			relabeled = append(relabeled, t)
+		}
+		element = copyMap(element)
+		element["@type"] = relabeled
+	}
+
+	switch {
+	case isValueObject(element):
+		if list == nil {
+			addUniqueValue(subjectNode, activeProperty, element)
+		} else {
+			list["@list"] = append(asArrayOrEmpty(list["@list"]), element)
+		}
+	case isListObject(element):
+		result := map[string]interface{}{"@list": []interface{}{}}
+		if err := nodes.generate(element["@list"], issuer, activeGraph, activeSubject, activeProperty,
+			result); err != nil {
+			return err
+		}
+		if list == nil {
+			subjectNode[activeProperty] = append(asArrayOrEmpty(subjectNode[activeProperty]), result)
+		} else {
+			list["@list"] = append(asArrayOrEmpty(list["@list"]), result)
+		}
+	default:
+		var id string
+		if s, ok := element["@id"].(string); ok {
+			id = s
+			if strings.HasPrefix(s, "_:") {
+				id = issuer.issue(s)
+			}
+		} else {
+			id = issuer.issue("")
+		}
+
+		node, ok := graph[id]
+		if !ok {
+			node = map[string]interface{}{"@id": id}
+			graph[id] = node
+		}
+
+		reference := map[string]interface{}{"@id": id}
+		if subject, ok := activeSubject.(map[string]interface{}); ok {
+			addUniqueValue(node, activeProperty, subject)
+		} else if activeProperty != "" {
+			if list == nil {
+				addUniqueValue(subjectNode, activeProperty, reference)
+			} else {
+				list["@list"] = append(asArrayOrEmpty(list["@list"]), reference)
+			}
+		}
+
+		for _, key := range sortedKeys(element) {
+			value := element[key]
+			switch key {
+			case "@id":
+			case "@type":
+				for _, t := range asArray(value) {
+					addUniqueValue(node, "@type", t)
+				}
+			case "@index":
+				if existing, ok := node["@index"]; ok && !reflect.DeepEqual(existing, value) {
+					return jsonldError("conflicting indexes", nil)
+				}
+				node["@index"] = value
+			case "@reverse":
+				reverse, _ := value.(map[string]interface{})
+				for _, property := range sortedKeys(reverse) {
+					if err := nodes.generate(reverse[property], issuer, activeGraph, reference, property,
+						nil); err != nil {
+						return err
+					}
+				}
+			case "@graph":
+				if err := nodes.generate(value, issuer, id, nil, "", nil); err != nil {
+					return err
+				}
+			case "@included":
+				if err := nodes.generate(value, issuer, activeGraph, nil, "", nil); err != nil {
+					return err
+				}
+			default:
+				if strings.HasPrefix(key, "@") {
+					continue
+				}
+				property := key
+				if strings.HasPrefix(property, "_:") {
+					property = issuer.issue(property)
+				}
+				if _, ok := node[property]; !ok {
+					node[property] = []interface{}{}
+				}
+				if err := nodes.generate(value, issuer, activeGraph, id, property, nil); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// graph returns the nodes of the named graph, adding it if needed.
+func (nodes nodeMap) graph(name string) map[string]map[string]interface{} {
+	graph, ok := nodes[name]
+	if !ok {
+		graph = make(map[string]map[string]interface{})
+		nodes[name] = graph
+	}
+	return graph
+}
+
+// merged implements the Merge Node Maps algorithm, see https://www.w3.org/TR/json-ld11-api/#merge-node-maps: it
+// returns the nodes of all graphs, with the values of the nodes that are in several graphs merged.
+func (nodes nodeMap) merged() map[string]map[string]interface{} {
+	result := make(map[string]map[string]interface{})
+	for _, name := range sortedGraphNames(nodes) {
+		for id, node := range nodes[name] {
+			merged, ok := result[id]
+			if !ok {
+				merged = map[string]interface{}{"@id": id}
+				result[id] = merged
+			}
+			for property, values := range node {
+				if property != "@type" && isKeyword(property) {
+					merged[property] = values
+					continue
+				}
+				if _, ok := merged[property]; !ok {
+					merged[property] = []interface{}{}
+				}
+				for _, v := range asArray(values) {
+					addUniqueValue(merged, property, v)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// sortedGraphNames returns the graph names of the node map, "@default" first.
+func sortedGraphNames(nodes nodeMap) []string {
+	names := make([]string, 0, len(nodes))
+	if _, ok := nodes["@default"]; ok {
+		names = append(names, "@default")
+	}
+	var others []string
+	for name := range nodes {
+		if name != "@default" {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	return append(names, others...)
+}
+
+// sortedNodeIDs returns the @id of the nodes of the graph in lexicographical order.
+func sortedNodeIDs(graph map[string]map[string]interface{}) []string {
+	ids := make([]string, 0, len(graph))
+	for id := range graph {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// addUniqueValue appends the value to the entry of the map unless the entry already has an equal value.
+func addUniqueValue(m map[string]interface{}, key string, value interface{}) {
+	if m == nil {
+		return
+	}
+	values := asArrayOrEmpty(m[key])
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return
+		}
+	}
+	m[key] = append(values, value)
+}
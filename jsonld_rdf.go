@@ -0,0 +1,192 @@
+package microdata
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// RDF returns the items as an RDF graph, see ToRDF, by way of their expanded JSON-LD, see Microdata.Expand.
+func (m *Microdata) RDF(opts ...JSONLDOption) (*Graph, error) {
+	expanded, err := m.Expand(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return ToRDF(expanded, append([]JSONLDOption{WithBaseIRI(m.BaseURL)}, opts...)...)
+}
+
+// ToRDF implements the Deserialize JSON-LD to RDF algorithm, see
+// https://www.w3.org/TR/json-ld11-api/#deserialize-json-ld-to-rdf-algorithm. The document is expanded first, so
+// that it may be in any form. Named graphs are merged into the default graph, and nodes, types and properties
+// that aren't absolute IRIs or blank nodes are skipped, like blank node properties. Blank nodes are labeled b0,
+// b1, etc. Errors are of type *JSONLDError.
+func ToRDF(doc interface{}, opts ...JSONLDOption) (*Graph, error) {
+	expanded, err := Expand(doc, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	issuer := newBlankNodeIssuer("_:b")
+	nodes := nodeMap{"@default": {}}
+	if err := nodes.generate(expanded, issuer, "@default", nil, "", nil); err != nil {
+		return nil, err
+	}
+
+	g := &Graph{}
+	subjects := nodes.merged()
+	for _, id := range sortedNodeIDs(subjects) {
+		subject, ok := rdfResource(id)
+		if !ok {
+			continue
+		}
+		node := subjects[id]
+		for _, property := range sortedKeys(node) {
+			switch {
+			case property == "@type":
+				for _, t := range asArray(node[property]) {
+					if s, ok := t.(string); ok {
+						if object, ok := rdfResource(s); ok {
+							g.add(subject, NewIRI(rdfType), object)
+						}
+					}
+				}
+			case isKeyword(property) || !isAbsoluteIRI(property) || strings.HasPrefix(property, "_:"):
+			default:
+				for _, item := range asArray(node[property]) {
+					if object, ok := g.objectToRDF(issuer, item); ok {
+						g.add(subject, NewIRI(property), object)
+					}
+				}
+			}
+		}
+	}
+	return g, nil
+}
+
+// rdfResource returns the IRI or blank node term of the node identifier, false if it is a relative IRI.
+func rdfResource(id string) (Term, bool) {
+	switch {
+	case strings.HasPrefix(id, "_:"):
+		return NewBlankNode(id[2:]), true
+	case isAbsoluteIRI(id):
+		return NewIRI(id), true
+	}
+	return Term{}, false
+}
+
+// objectToRDF returns the term of the expanded value, adding the triples of lists to the graph, see
+// https://www.w3.org/TR/json-ld11-api/#object-to-rdf-conversion.
+func (g *Graph) objectToRDF(issuer *blankNodeIssuer, item interface{}) (Term, bool) {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return Term{}, false
+	}
+	switch {
+	case isListObject(m):
+		return g.listToRDF(issuer, asArrayOrEmpty(m["@list"])), true
+	case isValueObject(m):
+		return valueToRDF(m)
+	default:
+		id, ok := m["@id"].(string)
+		if !ok {
+			return Term{}, false
+		}
+		return rdfResource(id)
+	}
+}
+
+// listToRDF adds the triples of the list to the graph and returns its head, see
+// https://www.w3.org/TR/json-ld11-api/#list-to-rdf-conversion.
+func (g *Graph) listToRDF(issuer *blankNodeIssuer, list []interface{}) Term {
+	if len(list) == 0 {
+		return NewIRI(rdfNil)
+	}
+	cells := make([]Term, len(list))
+	for i := range list {
+		cells[i] = NewBlankNode(issuer.issue("")[2:])
+	}
+	for i, item := range list {
+		if object, ok := g.objectToRDF(issuer, item); ok {
+			g.add(cells[i], NewIRI(rdfFirst), object)
+		}
+		rest := NewIRI(rdfNil)
+		if i+1 < len(cells) {
+			rest = cells[i+1]
+		}
+		g.add(cells[i], NewIRI(rdfRest), rest)
+	}
+	return cells[0]
+}
+
+// valueToRDF returns the literal of the value object. Booleans and numbers are typed xsd:boolean, xsd:integer or
+// xsd:double unless the value object has a type, and their lexical forms are canonical.
+func valueToRDF(m map[string]interface{}) (Term, bool) {
+	datatype, _ := m["@type"].(string)
+	if datatype == "@json" {
+		var b bytes.Buffer
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(m["@value"]); err != nil {
+			return Term{}, false
+		}
+		return NewLiteral(strings.TrimSuffix(b.String(), "\n"), rdfJSON, ""), true
+	}
+	if datatype != "" && !isAbsoluteIRI(datatype) {
+		return Term{}, false
+	}
+
+	var value string
+	switch v := m["@value"].(type) {
+	case bool:
+		value = strconv.FormatBool(v)
+		if datatype == "" {
+			datatype = xsdBoolean
+		}
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e21 && datatype != xsdDouble {
+			value = strconv.FormatFloat(v, 'f', -1, 64)
+			if datatype == "" {
+				datatype = xsdInteger
+			}
+		} else {
+			value = canonicalDouble(v)
+			if datatype == "" {
+				datatype = xsdDouble
+			}
+		}
+	case int:
+		value = strconv.Itoa(v)
+		if datatype == "" {
+			datatype = xsdInteger
+		}
+	case string:
+		value = v
+	default:
+		return Term{}, false
+	}
+
+	language, _ := m["@language"].(string)
+	if language != "" {
+		datatype = ""
+	}
+	return NewLiteral(value, datatype, language), true
+}
+
+// canonicalDouble returns the canonical lexical form of the xsd:double, e.g. "1.5E1".
+func canonicalDouble(v float64) string {
+	s := strconv.FormatFloat(v, 'E', -1, 64)
+	mantissa, exponent, ok := strings.Cut(s, "E")
+	if !ok {
+		return s
+	}
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+	e, err := strconv.Atoi(exponent)
+	if err != nil {
+		return s
+	}
+	return mantissa + "E" + strconv.Itoa(e)
+}
@@ -0,0 +1,462 @@
+package microdata
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// expandJSON expands the JSON-LD document and returns the result as JSON.
+func expandJSON(doc string, t *testing.T, opts ...JSONLDOption) string {
+	var v interface{}
+	if err := json.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatal(err)
+	}
+	expanded, err := Expand(v, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(expanded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestExpandSchemaOrg(t *testing.T) {
+	doc := `{
+		"@context": "https://schema.org",
+		"id": "#jane",
+		"type": "Person",
+		"name": "Jane",
+		"url": "/jane",
+		"sameAs": ["https://example.org/jane"],
+		"birthDate": "2000-01-01"
+	}`
+
+	result := expandJSON(doc, t, WithBaseIRI("https://example.com/page"))
+	expected := `[{"@id":"https://example.com/page#jane","@type":["http://schema.org/Person"],"http://schema.org/birthDate":[{"@type":"http://schema.org/Date","@value":"2000-01-01"}],"http://schema.org/name":[{"@value":"Jane"}],"http://schema.org/sameAs":[{"@id":"https://example.org/jane"}],"http://schema.org/url":[{"@id":"https://example.com/jane"}]}]`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
+
+func TestExpandContext(t *testing.T) {
+	doc := `{
+		"@context": {
+			"s": "http://schema.org/",
+			"title": {"@id": "s:name", "@language": "en"},
+			"kids": {"@id": "s:children", "@container": "@list"},
+			"labels": {"@id": "s:alternateName", "@container": "@language"},
+			"parent": {"@reverse": "s:children"},
+			"props": "@nest",
+			"Book": {"@id": "s:Book", "@context": {"pages": "s:numberOfPages"}}
+		},
+		"@id": "_:book",
+		"@type": "Book",
+		"title": "Go",
+		"s:author": {"s:name": "Ann", "pages": 1},
+		"kids": ["a", "b"],
+		"labels": {"de": "Los", "@none": "Plain"},
+		"parent": {"@id": "https://example.com/parent"},
+		"props": {"s:isbn": "123"},
+		"pages": 300
+	}`
+
+	result := expandJSON(doc, t)
+	expected := `[{"@id":"_:book","@reverse":{"http://schema.org/children":[{"@id":"https://example.com/parent"}]},"@type":["http://schema.org/Book"],"http://schema.org/alternateName":[{"@value":"Plain"},{"@language":"de","@value":"Los"}],"http://schema.org/author":[{"http://schema.org/name":[{"@value":"Ann"}]}],"http://schema.org/children":[{"@list":[{"@value":"a"},{"@value":"b"}]}],"http://schema.org/isbn":[{"@value":"123"}],"http://schema.org/name":[{"@language":"en","@value":"Go"}],"http://schema.org/numberOfPages":[{"@value":300}]}]`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
+
+func TestExpandContainers(t *testing.T) {
+	doc := `{
+		"@context": {
+			"@version": 1.1,
+			"@vocab": "https://example.com/",
+			"@base": "https://example.com/base/",
+			"idx": {"@container": "@index"},
+			"byId": {"@container": "@id"},
+			"byType": {"@container": "@type"},
+			"data": {"@type": "@json"}
+		},
+		"@graph": [
+			{"@id": "a", "idx": {"en": "x", "fr": {"@value": "y"}}, "byId": {"b": {"v": "1"}}},
+			{"@id": "c", "byType": {"T": {"@id": "d"}}, "data": {"a": [1, 2]}}
+		]
+	}`
+
+	result := expandJSON(doc, t)
+	expected := `[{"@id":"https://example.com/base/a","https://example.com/byId":[{"@id":"https://example.com/base/b","https://example.com/v":[{"@value":"1"}]}],"https://example.com/idx":[{"@index":"en","@value":"x"},{"@index":"fr","@value":"y"}]},{"@id":"https://example.com/base/c","https://example.com/byType":[{"@id":"https://example.com/base/d","@type":["https://example.com/T"]}],"https://example.com/data":[{"@type":"@json","@value":{"a":[1,2]}}]}]`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
+
+func TestExpandDocumentLoader(t *testing.T) {
+	loader := func(url string) (interface{}, error) {
+		if url != "https://example.com/context.jsonld" {
+			return DefaultDocumentLoader(url)
+		}
+		return map[string]interface{}{
+			"@context": map[string]interface{}{"headline": "https://example.com/vocab#title"},
+		}, nil
+	}
+
+	doc := `{"@context": ["https://schema.org", "/context.jsonld"], "headline": "Hello", "name": "World"}`
+	result := expandJSON(doc, t, WithBaseIRI("https://example.com/page"), WithDocumentLoader(loader))
+	expected := `[{"http://schema.org/name":[{"@value":"World"}],"https://example.com/vocab#title":[{"@value":"Hello"}]}]`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+
+	result = expandJSON(`{"@type": "Person", "name": "Jane"}`, t, WithExpandContext("https://schema.org"))
+	expected = `[{"@type":["http://schema.org/Person"],"http://schema.org/name":[{"@value":"Jane"}]}]`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
+
+func TestExpandErrors(t *testing.T) {
+	tests := []struct {
+		doc  string
+		code string
+	}{
+		{`{"@context": "https://example.com/context.jsonld", "name": "x"}`, "loading remote context failed"},
+		{`{"@id": 1}`, "invalid @id value"},
+		{`{"@context": {"name": {"@id": "http://schema.org/name", "@container": "@foo"}}}`, "invalid container mapping"},
+		{`{"@context": {"a": "b:c", "b": "a:d"}, "a": 1}`, "cyclic IRI mapping"},
+		{`{"@context": {"@type": "x"}}`, "keyword redefinition"},
+		{`{"http://example.com/p": {"@value": "x", "@language": "en", "@type": "http://example.com/T"}}`, "invalid value object"},
+	}
+
+	for _, test := range tests {
+		var v interface{}
+		if err := json.Unmarshal([]byte(test.doc), &v); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Expand(v)
+		var jsonldErr *JSONLDError
+		if !errors.As(err, &jsonldErr) || jsonldErr.Code != test.code {
+			t.Errorf("Result should have been a \"%s\" error, but it was %v", test.code, err)
+		}
+	}
+
+	var v interface{}
+	_ = json.Unmarshal([]byte(`{"@context": "https://example.com/context.jsonld"}`), &v)
+	if _, err := Expand(v); !errors.Is(err, ErrDocumentNotFound) {
+		t.Errorf("Result should have been ErrDocumentNotFound, but it was %v", err)
+	}
+}
+
+func TestParseJSONLDExpansion(t *testing.T) {
+	html := `<script type="application/ld+json">{
+		"@context": {"@vocab": "http://schema.org/", "s": "http://schema.org/", "title": "s:name", "Org": "s:Organization"},
+		"@id": "#org",
+		"@type": "Org",
+		"title": "Example",
+		"s:logo": {"@id": "/logo.png"},
+		"founder": {"@type": "Person", "title": "Jane"}
+	}</script>
+	<script type="application/ld+json">{"@id": 1, "name": "invalid"}</script>`
+
+	data, err := ParseHTML(strings.NewReader(html), "text/html; charset=utf-8", "https://example.com/about",
+		WithJSONLDExpansion())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(data.Items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(b)
+	expected := `[{"type":["http://schema.org/Organization"],"properties":{"http://schema.org/founder":[{"type":["http://schema.org/Person"],"properties":{"http://schema.org/name":["Jane"]}}],"http://schema.org/logo":[{"type":[],"properties":{},"id":"https://example.com/logo.png"}],"http://schema.org/name":["Example"]},"id":"https://example.com/about#org"},{"type":[],"properties":{"@id":[1],"name":["invalid"]}}]`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+
+	if len(data.JSONLD) != 1 {
+		t.Errorf("Result should have been 1 expanded node, but it was %d", len(data.JSONLD))
+	}
+}
+
+func TestMicrodataExpandValueObjects(t *testing.T) {
+	html := `<script type="application/ld+json">{
+		"@context": "https://schema.org",
+		"@type": "Person",
+		"name": {"@value": "Jane", "@language": "en"},
+		"birthDate": {"@value": "2020-01-01", "@type": "Date"}
+	}</script>`
+
+	expanded, err := ParseData(html, t).Expand()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(expanded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(b)
+	expected := `[{"@type":["http://schema.org/Person"],"http://schema.org/birthDate":[{"@type":"http://schema.org/Date","@value":"2020-01-01"}],"http://schema.org/name":[{"@language":"en","@value":"Jane"}]}]`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
+
+func TestMicrodataExpandEmptyKeywords(t *testing.T) {
+	item := NewItem()
+	item.addType("https://schema.org/Person")
+	item.Properties["@id"] = ValueList{}
+	item.Properties["@language"] = ValueList{}
+	item.Properties["@index"] = ValueList{}
+	item.addProperty("https://schema.org/name", "Jane")
+
+	data := &Microdata{Items: []*Item{item}}
+	expanded, err := data.Expand()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(expanded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(b)
+	expected := `[{"@type":["http://schema.org/Person"],"http://schema.org/name":[{"@value":"Jane"}]}]`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
+
+func TestMicrodataExpandUntyped(t *testing.T) {
+	html := `<div itemscope itemtype="https://schema.org/Person">
+		<div itemprop="address" itemscope><span itemprop="streetAddress">Main Street</span></div>
+	</div>
+	<div itemscope><span itemprop="name">Dropped</span></div>`
+
+	expanded, err := ParseData(html, t).Expand()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(expanded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(b)
+	expected := `[{"@type":["http://schema.org/Person"],"http://schema.org/address":[{"http://schema.org/streetAddress":[{"@value":"Main Street"}]}]}]`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	html := `<div itemscope itemtype="https://schema.org/Person" itemid="https://example.com/page#jane">
+			<span itemprop="name">Jane</span>
+			<div itemprop="address" itemscope itemtype="https://schema.org/PostalAddress">
+				<span itemprop="addressLocality">Kyiv</span>
+			</div>
+		</div>
+		<script type="application/ld+json">{"@context": "https://schema.org", "@type": "Organization", "name": "Org"}</script>
+		<script type="application/ld+json">{"@context": {"s": "http://schema.org/"}, "@type": "s:Thing", "s:name": "Thing"}</script>`
+
+	data, err := ParseHTML(strings.NewReader(html), "text/html; charset=utf-8", "https://example.com/page")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		context  interface{}
+		expected string
+	}{
+		{
+			"https://schema.org",
+			`{"@context":"https://schema.org","@graph":[{"address":{"addressLocality":"Kyiv","type":"PostalAddress"},"id":"#jane","name":"Jane","type":"Person"},{"name":"Org","type":"Organization"},{"name":"Thing","type":"Thing"}]}`,
+		},
+		{
+			map[string]interface{}{"@context": map[string]interface{}{"schema": "http://schema.org/"}},
+			`{"@context":{"schema":"http://schema.org/"},"@graph":[{"@id":"#jane","@type":"schema:Person","schema:address":{"@type":"schema:PostalAddress","schema:addressLocality":"Kyiv"},"schema:name":"Jane"},{"@type":"schema:Organization","schema:name":"Org"},{"@type":"schema:Thing","schema:name":"Thing"}]}`,
+		},
+	}
+
+	for _, test := range tests {
+		compacted, err := Compact(data, test.context)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := json.Marshal(compacted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result := string(b); result != test.expected {
+			t.Errorf("Result should have been \"%s\", but it was \"%s\"", test.expected, result)
+		}
+	}
+}
+
+func TestCompactJSONLD(t *testing.T) {
+	context := map[string]interface{}{
+		"@vocab":  "https://example.com/",
+		"tags":    map[string]interface{}{"@container": "@list"},
+		"label":   map[string]interface{}{"@id": "https://example.com/label", "@container": "@language"},
+		"byId":    map[string]interface{}{"@id": "https://example.com/byId", "@container": "@id"},
+		"knownBy": map[string]interface{}{"@reverse": "https://example.com/knows"},
+		"home":    map[string]interface{}{"@id": "https://example.com/home", "@type": "@id"},
+	}
+	doc := `[{
+		"@id": "https://example.com/a",
+		"https://example.com/tags": {"@list": ["x", "y"]},
+		"https://example.com/label": [{"@value": "Hi", "@language": "en"}, {"@value": "Hallo", "@language": "de"}],
+		"https://example.com/byId": {"@id": "https://example.com/b", "https://example.com/n": "1"},
+		"@reverse": {"https://example.com/knows": {"@id": "https://example.com/c"}},
+		"https://example.com/home": {"@id": "https://example.com/a/home"}
+	}]`
+
+	var v interface{}
+	if err := json.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatal(err)
+	}
+	compacted, err := CompactJSONLD(v, context, WithBaseIRI("https://example.com/a/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	delete(compacted, "@context")
+
+	b, err := json.Marshal(compacted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(b)
+	expected := `{"@id":"../a","byId":{"../b":{"n":"1"}},"home":"home","knownBy":{"@id":"../c"},"label":{"de":"Hallo","en":"Hi"},"tags":["x","y"]}`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
+
+func TestFrame(t *testing.T) {
+	html := `<div itemscope itemtype="https://schema.org/Product" itemid="https://example.com/#shoe">
+			<span itemprop="name">Shoe</span>
+			<div itemprop="offers" itemscope itemtype="https://schema.org/Offer">
+				<span itemprop="price">10</span>
+			</div>
+		</div>
+		<script type="application/ld+json">{
+			"@context": "https://schema.org",
+			"@graph": [
+				{"@type": "Product", "@id": "#shoe", "brand": {"@id": "#acme"}, "sku": "1"},
+				{"@type": "Brand", "@id": "#acme", "name": "ACME", "owns": {"@id": "#shoe"}},
+				{"@type": "Product", "@id": "#sock", "name": "Sock"},
+				{"@type": "Person", "@id": "#jane", "name": "Jane", "birthDate": "2000-01-01"}
+			]
+		}</script>`
+
+	data, err := ParseHTML(strings.NewReader(html), "text/html; charset=utf-8", "https://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		frame    map[string]interface{}
+		expected string
+	}{
+		{
+			map[string]interface{}{
+				"@context": "https://schema.org",
+				"@type":    "Product",
+				"offers":   map[string]interface{}{"@type": "Offer"},
+				"brand":    map[string]interface{}{},
+			},
+			`{"@context":"https://schema.org","@graph":[{"brand":{"id":"#acme","name":"ACME","owns":{"id":"#shoe"},"type":"Brand"},"id":"#shoe","name":"Shoe","offers":{"price":"10","type":"Offer"},"sku":"1","type":"Product"},{"brand":null,"id":"#sock","name":"Sock","offers":null,"type":"Product"}]}`,
+		},
+		{
+			map[string]interface{}{
+				"@context":    "https://schema.org",
+				"@type":       "Product",
+				"@explicit":   true,
+				"@requireAll": true,
+				"name":        map[string]interface{}{},
+				"brand":       map[string]interface{}{"@embed": "@never"},
+				"color":       map[string]interface{}{"@default": "black"},
+				"sku":         map[string]interface{}{"@omitDefault": true},
+			},
+			`{"@context":"https://schema.org","brand":{"id":"#acme"},"color":"black","id":"#shoe","name":"Shoe","sku":"1","type":"Product"}`,
+		},
+		{
+			map[string]interface{}{
+				"@context":  "https://schema.org",
+				"@type":     "Person",
+				"birthDate": map[string]interface{}{"@type": "http://schema.org/Date"},
+			},
+			`{"@context":"https://schema.org","birthDate":"2000-01-01","id":"#jane","name":"Jane","type":"Person"}`,
+		},
+		{
+			map[string]interface{}{"@context": "https://schema.org", "@type": "Event"},
+			`{"@context":"https://schema.org","@graph":[]}`,
+		},
+	}
+
+	for _, test := range tests {
+		framed, err := Frame(data, test.frame)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := json.Marshal(framed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result := string(b); result != test.expected {
+			t.Errorf("Result should have been \"%s\", but it was \"%s\"", test.expected, result)
+		}
+	}
+
+	_, err = Frame(data, map[string]interface{}{"@embed": "@link"})
+	var jsonldErr *JSONLDError
+	if !errors.As(err, &jsonldErr) || jsonldErr.Code != "invalid @embed value" {
+		t.Errorf("Result should have been an invalid @embed value error, but it was %v", err)
+	}
+}
+
+func TestToRDF(t *testing.T) {
+	doc := `{
+		"@context": {"@vocab": "http://schema.org/", "tags": {"@container": "@list"}},
+		"@id": "https://example.com/#shoe",
+		"@type": "Product",
+		"name": {"@value": "Schuh", "@language": "de"},
+		"tags": ["a", "b"],
+		"price": 9.5,
+		"stock": 3,
+		"birthDate": {"@value": "2000-01-01", "@type": "http://schema.org/Date"},
+		"isNew": true,
+		"brand": {"name": "ACME"},
+		"relative": {"@id": "shoe"}
+	}`
+
+	var v interface{}
+	if err := json.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatal(err)
+	}
+	g, err := ToRDF(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := g.String()
+	expected := `_:b0 <http://schema.org/name> "ACME" .
+<https://example.com/#shoe> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://schema.org/Product> .
+<https://example.com/#shoe> <http://schema.org/birthDate> "2000-01-01"^^<http://schema.org/Date> .
+<https://example.com/#shoe> <http://schema.org/brand> _:b0 .
+<https://example.com/#shoe> <http://schema.org/isNew> "true"^^<http://www.w3.org/2001/XMLSchema#boolean> .
+<https://example.com/#shoe> <http://schema.org/name> "Schuh"@de .
+<https://example.com/#shoe> <http://schema.org/price> "9.5E0"^^<http://www.w3.org/2001/XMLSchema#double> .
+<https://example.com/#shoe> <http://schema.org/stock> "3"^^<http://www.w3.org/2001/XMLSchema#integer> .
+_:b1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> "a" .
+_:b1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> _:b2 .
+_:b2 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> "b" .
+_:b2 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> <http://www.w3.org/1999/02/22-rdf-syntax-ns#nil> .
+<https://example.com/#shoe> <http://schema.org/tags> _:b1 .
+`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
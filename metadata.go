@@ -0,0 +1,158 @@
+package microdata
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// DocumentMetadata holds the page-level metadata of a document found in its <title>, <meta> and <link> elements.
+// When an element that takes a single value occurs more than once, the first occurrence is used.
+type DocumentMetadata struct {
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Keywords    []string `json:"keywords,omitempty"`
+	// Canonical is the resolved href of the <link rel="canonical"> element.
+	Canonical string `json:"canonical,omitempty"`
+	// Alternates are the <link rel="alternate" hreflang> language versions of the document.
+	Alternates []AlternateLink `json:"alternates,omitempty"`
+	// DublinCore holds the values of the DC.* and DCTERMS.* meta elements keyed by the element name without the
+	// prefix, e.g. "creator" for DC.creator.
+	DublinCore map[string][]string `json:"dublinCore,omitempty"`
+	// Citation holds the Highwire Press citation_* meta elements used by Google Scholar.
+	Citation *Citation `json:"citation,omitempty"`
+}
+
+// AlternateLink is a language version of a document.
+type AlternateLink struct {
+	HrefLang string `json:"hreflang"`
+	URL      string `json:"url"`
+}
+
+// Citation holds the Highwire Press citation metadata of a scholarly document.
+type Citation struct {
+	Title           string   `json:"title,omitempty"`
+	Authors         []string `json:"authors,omitempty"`
+	PublicationDate string   `json:"publicationDate,omitempty"`
+	JournalTitle    string   `json:"journalTitle,omitempty"`
+	Publisher       string   `json:"publisher,omitempty"`
+	Volume          string   `json:"volume,omitempty"`
+	Issue           string   `json:"issue,omitempty"`
+	FirstPage       string   `json:"firstPage,omitempty"`
+	LastPage        string   `json:"lastPage,omitempty"`
+	DOI             string   `json:"doi,omitempty"`
+	ISSN            string   `json:"issn,omitempty"`
+	ISBN            string   `json:"isbn,omitempty"`
+	PDFURL          string   `json:"pdfUrl,omitempty"`
+	AbstractURL     string   `json:"abstractUrl,omitempty"`
+	FullTextURL     string   `json:"fullTextUrl,omitempty"`
+	// Properties holds the values of all the citation_* meta elements in document order, keyed by their name
+	// without the prefix, including the ones without a field.
+	Properties map[string][]string `json:"properties,omitempty"`
+}
+
+// readDocumentMetadata adds the metadata found in the given node to m.
+func (p *parser) readDocumentMetadata(n *html.Node, m *DocumentMetadata) {
+	if n.Type != html.ElementNode || n.Namespace != "" {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Title:
+		if m.Title == "" {
+			m.Title = strings.Join(strings.Fields(textContent(n)), " ")
+		}
+	case atom.Link:
+		rel, _ := getAttr("rel", n)
+		href, ok := getAttr("href", n)
+		if !ok {
+			return
+		}
+		if containsToken(rel, "canonical") {
+			setFirst(&m.Canonical, p.resolveURL(href))
+		}
+		if hreflang, ok := getAttr("hreflang", n); ok && containsToken(rel, "alternate") {
+			m.Alternates = append(m.Alternates, AlternateLink{HrefLang: strings.TrimSpace(hreflang), URL: p.resolveURL(href)})
+		}
+	case atom.Meta:
+		name, _ := getAttr("name", n)
+		content, ok := getAttr("content", n)
+		if !ok {
+			return
+		}
+		name, content = strings.TrimSpace(name), strings.TrimSpace(content)
+		lower := strings.ToLower(name)
+
+		switch {
+		case lower == "description":
+			setFirst(&m.Description, content)
+		case lower == "keywords":
+			if m.Keywords == nil {
+				for _, k := range strings.Split(content, ",") {
+					if k = strings.TrimSpace(k); k != "" {
+						m.Keywords = append(m.Keywords, k)
+					}
+				}
+			}
+		case strings.HasPrefix(lower, "dc.") || strings.HasPrefix(lower, "dcterms."):
+			key := name[strings.IndexByte(name, '.')+1:]
+			if key == "" {
+				return
+			}
+			if m.DublinCore == nil {
+				m.DublinCore = make(map[string][]string)
+			}
+			m.DublinCore[key] = append(m.DublinCore[key], content)
+		case strings.HasPrefix(lower, "citation_"):
+			if m.Citation == nil {
+				m.Citation = &Citation{Properties: make(map[string][]string)}
+			}
+			p.addCitation(m.Citation, lower[len("citation_"):], content)
+		}
+	}
+}
+
+// addCitation adds the value of the citation_<name> meta element to the citation.
+func (p *parser) addCitation(c *Citation, name, value string) {
+	c.Properties[name] = append(c.Properties[name], value)
+
+	switch name {
+	case "title":
+		setFirst(&c.Title, value)
+	case "author":
+		c.Authors = append(c.Authors, value)
+	case "publication_date", "date":
+		setFirst(&c.PublicationDate, value)
+	case "journal_title":
+		setFirst(&c.JournalTitle, value)
+	case "publisher":
+		setFirst(&c.Publisher, value)
+	case "volume":
+		setFirst(&c.Volume, value)
+	case "issue":
+		setFirst(&c.Issue, value)
+	case "firstpage":
+		setFirst(&c.FirstPage, value)
+	case "lastpage":
+		setFirst(&c.LastPage, value)
+	case "doi":
+		setFirst(&c.DOI, value)
+	case "issn":
+		setFirst(&c.ISSN, value)
+	case "isbn":
+		setFirst(&c.ISBN, value)
+	case "pdf_url":
+		setFirst(&c.PDFURL, p.resolveURL(value))
+	case "abstract_html_url":
+		setFirst(&c.AbstractURL, p.resolveURL(value))
+	case "fulltext_html_url":
+		setFirst(&c.FullTextURL, p.resolveURL(value))
+	}
+}
+
+// isEmpty returns true if no metadata was found.
+func (m *DocumentMetadata) isEmpty() bool {
+	return m.Title == "" && m.Description == "" && m.Keywords == nil && m.Canonical == "" && m.Alternates == nil &&
+		m.DublinCore == nil && m.Citation == nil
+}
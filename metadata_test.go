@@ -0,0 +1,78 @@
+package microdata
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseDocumentMetadata(t *testing.T) {
+	html := `<html><head>
+		<base href="/papers/">
+		<title>
+			On the   Electrodynamics of Moving Bodies
+		</title>
+		<meta name="description" content="A famous paper.">
+		<meta name="Keywords" content="relativity, physics, ">
+		<link rel="canonical" href="electrodynamics">
+		<link rel="alternate" hreflang="de" href="/de/papers/elektrodynamik">
+		<link rel="alternate" hreflang="x-default" href="electrodynamics">
+		<meta name="DC.creator" content="Einstein, Albert">
+		<meta name="DCTERMS.issued" content="1905-06-30">
+		<meta name="citation_title" content="Zur Elektrodynamik bewegter Körper">
+		<meta name="citation_author" content="Einstein, Albert">
+		<meta name="citation_author" content="Second, Author">
+		<meta name="citation_publication_date" content="1905/06/30">
+		<meta name="citation_journal_title" content="Annalen der Physik">
+		<meta name="citation_volume" content="322">
+		<meta name="citation_pdf_url" content="electrodynamics.pdf">
+	</head><body><svg><title>Not the title</title></svg></body></html>`
+
+	data, err := ParseHTML(strings.NewReader(html), "text/html; charset=utf-8", "https://example.org/index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(data.Metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(b)
+	expected := `{"title":"On the Electrodynamics of Moving Bodies","description":"A famous paper.","keywords":["relativity","physics"],"canonical":"https://example.org/papers/electrodynamics","alternates":[{"hreflang":"de","url":"https://example.org/de/papers/elektrodynamik"},{"hreflang":"x-default","url":"https://example.org/papers/electrodynamics"}],"dublinCore":{"creator":["Einstein, Albert"],"issued":["1905-06-30"]},"citation":{"title":"Zur Elektrodynamik bewegter Körper","authors":["Einstein, Albert","Second, Author"],"publicationDate":"1905/06/30","journalTitle":"Annalen der Physik","volume":"322","pdfUrl":"https://example.org/papers/electrodynamics.pdf","properties":{"author":["Einstein, Albert","Second, Author"],"journal_title":["Annalen der Physik"],"pdf_url":["electrodynamics.pdf"],"publication_date":["1905/06/30"],"title":["Zur Elektrodynamik bewegter Körper"],"volume":["322"]}}}`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
+
+func TestParseNoDocumentMetadata(t *testing.T) {
+	data := ParseData(`<div itemscope><span itemprop="name">Plain</span></div>`, t)
+	if data.Metadata != nil {
+		t.Errorf("Result should have been no metadata, but it was %+v", data.Metadata)
+	}
+}
+
+func TestParseMetadataJSON(t *testing.T) {
+	html := `<title>Page</title><meta property="og:title" content="Page">`
+	for _, test := range []struct {
+		opts     []ParseOption
+		expected string
+	}{
+		{nil, `{"items":null}`},
+		{[]ParseOption{WithMetadataJSON()}, `{"items":null,"metadata":{"title":"Page"},"openGraph":{"title":"Page","properties":{"og:title":["Page"]}}}`},
+	} {
+		data, err := ParseHTML(strings.NewReader(html), "text/html; charset=utf-8", "https://example.com", test.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Pointers, values and embedded values are marshaled alike.
+		for _, v := range []interface{}{data, *data, struct{ Microdata }{*data}} {
+			b, err := json.Marshal(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result := string(b); result != test.expected {
+				t.Errorf("Result should have been \"%s\", but it was \"%s\"", test.expected, result)
+			}
+		}
+	}
+}
@@ -1,60 +1,124 @@
 package microdata
 
 import (
-	"bytes"
+	"context"
 	"golang.org/x/net/html"
-	"golang.org/x/net/html/charset"
 	"io"
-	"net/http"
 	"net/url"
 )
 
 // ParseURL parses the HTML document available at the given URL and returns the microdata.
+// It uses a Fetcher with the default options, see ParseURLContext for more control.
 func ParseURL(urlStr string) (*Microdata, error) {
-	resp, err := http.DefaultClient.Get(urlStr)
-	if err != nil {
-		return nil, err
+	return ParseURLContext(context.Background(), urlStr)
+}
+
+// ParseURLContext fetches the HTML document available at the given URL using a Fetcher configured with the
+// given options and returns the microdata. The request is bound to the given context.
+func ParseURLContext(ctx context.Context, urlStr string, opts ...FetchOption) (*Microdata, error) {
+	return NewFetcher(opts...).ParseURL(ctx, urlStr)
+}
+
+// ParseOption configures how a document is parsed.
+type ParseOption func(*parseOptions)
+
+type parseOptions struct {
+	encoding         string
+	openGraphItem    bool
+	microformatItems bool
+	embeddedJSON     bool
+	detectors        []EmbeddedJSONDetector
+	fragments        bool
+	jsonldExpansion  bool
+	jsonld           []JSONLDOption
+	flatten          bool
+	metadataJSON     bool
+}
+
+// WithEncoding decodes documents with the encoding of the given label, e.g. "shift_jis", instead of sniffing it.
+// A byte order mark still takes precedence, like in browsers.
+func WithEncoding(label string) ParseOption {
+	return func(o *parseOptions) {
+		o.encoding = label
 	}
+}
 
-	contentType := resp.Header.Get("Content-Type")
-	return ParseHTML(resp.Body, contentType, resp.Request.URL.String())
+// WithOpenGraphItem adds the Open Graph metadata of documents as an item to the items, besides Microdata.OpenGraph.
+// The item's type is the og:type in the http://ogp.me/ns# namespace and its properties are keyed by their full
+// names, e.g. "og:title".
+func WithOpenGraphItem() ParseOption {
+	return func(o *parseOptions) {
+		o.openGraphItem = true
+	}
+}
+
+// WithMetadataJSON writes the page-level metadata of documents, i.e. Microdata.Metadata, OpenGraph, Twitter,
+// Microformats and OEmbed, in their JSON besides the items, see Microdata.MarshalJSON.
+func WithMetadataJSON() ParseOption {
+	return func(o *parseOptions) {
+		o.metadataJSON = true
+	}
+}
+
+// WithMicroformatItems adds the top-level microformats of documents as items to the items, besides
+// Microdata.Microformats. See MicroformatItem.Item for the conversion.
+func WithMicroformatItems() ParseOption {
+	return func(o *parseOptions) {
+		o.microformatItems = true
+	}
+}
+
+// newParseOptions returns the options with the given functions applied.
+func newParseOptions(opts []ParseOption) parseOptions {
+	var o parseOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
 }
 
 // ParseHTML parses the HTML document available in the given reader and returns the microdata. The given url is
-// used to resolve the URLs in the attributes. The given contentType is used to convert the content of r to UTF-8.
-// When the given contentType is equal to "", the content type will be detected using `http.DetectContentType`.
-func ParseHTML(r io.Reader, contentType string, urlStr string) (*Microdata, error) {
-	if contentType == "" {
-		b := make([]byte, 512)
-		_, err := r.Read(b)
-		if err != nil {
-			return nil, err
-		}
-		contentType = http.DetectContentType(b)
-		r = io.MultiReader(bytes.NewReader(b), r)
-	}
-
-	r, err := charset.NewReader(r, contentType)
+// used to resolve the URLs in the attributes. The content of r is converted to UTF-8 using the WHATWG encoding
+// sniffing algorithm, taking the charset parameter of the given contentType into account. The encoding used is
+// reported in Microdata.Encoding.
+func ParseHTML(r io.Reader, contentType string, urlStr string, opts ...ParseOption) (*Microdata, error) {
+	tree, enc, err := parseDocument(r, contentType, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	tree, err := html.Parse(r)
+	data, err := ParseNode(tree, urlStr, opts...)
 	if err != nil {
 		return nil, err
 	}
+	data.Encoding = enc
+	return data, nil
+}
+
+// parseDocument converts the content of r to UTF-8 and returns the parsed tree and the encoding used.
+func parseDocument(r io.Reader, contentType string, opts []ParseOption) (*html.Node, *Encoding, error) {
+	o := newParseOptions(opts)
+
+	r, enc, err := decodeDocument(r, contentType, o.encoding)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	return ParseNode(tree, urlStr)
+	tree, err := html.Parse(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tree, enc, nil
 }
 
 // ParseNode parses the root Node and returns the microdata.
-func ParseNode(root *html.Node, urlStr string) (*Microdata, error) {
+func ParseNode(root *html.Node, urlStr string, opts ...ParseOption) (*Microdata, error) {
 	u, err := url.Parse(urlStr)
 	if err != nil {
 		return nil, err
 	}
 
-	p, err := newParser(root, u)
+	p, err := newParser(root, u, newParseOptions(opts))
 	if err != nil {
 		return nil, err
 	}
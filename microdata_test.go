@@ -8,6 +8,8 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"golang.org/x/net/html"
 )
 
 func TestParseItemScope(t *testing.T) {
@@ -322,6 +324,52 @@ func TestNestedItems(t *testing.T) {
 	}
 }
 
+func TestParseBaseHref(t *testing.T) {
+	html := `
+		<html>
+			<head><base href="https://cdn.example.net/mirror/"></head>
+			<body>
+				<div itemscope itemtype="https://schema.org/Product" itemid="#product">
+					<img itemprop="image" src="images/shoe.jpg">
+					<a itemprop="url" href="/shoe">Shoe</a>
+				</div>
+			</body>
+		</html>`
+
+	var testTable = []struct {
+		propName string
+		expected string
+	}{
+		{"image", "https://cdn.example.net/mirror/images/shoe.jpg"},
+		{"url", "https://cdn.example.net/shoe"},
+	}
+
+	data, err := ParseHTML(strings.NewReader(html), "charset=utf-8", "https://example.com/products/shoe")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range testTable {
+		if result := data.Items[0].Properties[test.propName][0].(string); result != test.expected {
+			t.Errorf("Result should have been \"%s\", but it was \"%s\"", test.expected, result)
+		}
+	}
+	if result := data.Items[0].ID; result != "https://cdn.example.net/mirror/#product" {
+		t.Errorf("Result should have been \"https://cdn.example.net/mirror/#product\", but it was \"%s\"", result)
+	}
+	if result := data.BaseURL; result != "https://cdn.example.net/mirror/" {
+		t.Errorf("Result should have been \"https://cdn.example.net/mirror/\", but it was \"%s\"", result)
+	}
+
+	data, err = ParseHTML(strings.NewReader(`<base href="../"><base href="/ignored/">`), "", "https://example.com/a/b/c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := data.BaseURL; result != "https://example.com/a/" {
+		t.Errorf("Result should have been \"https://example.com/a/\", but it was \"%s\"", result)
+	}
+}
+
 func ParseData(html string, t *testing.T) *Microdata {
 	r := strings.NewReader(html)
 
@@ -520,6 +568,45 @@ func TestParseMicrodataMultipleSnippet(t *testing.T) {
 	}
 }
 
+func TestParseJSONLDScriptVariants(t *testing.T) {
+	var testTable = []struct {
+		name   string
+		script string
+	}{
+		{"parameters", `<script type="application/ld+json; charset=utf-8">{"@type":"Thing"}</script>`},
+		{"case", `<script type="Application/LD+JSON">{"@type":"Thing"}</script>`},
+		{"whitespace", `<script type=" application/ld+json ">{"@type":"Thing"}</script>`},
+		{"comment", `<script type="application/ld+json"><!-- {"@type":"Thing"} --></script>`},
+		{"cdata", "<script type=\"application/ld+json\">//<![CDATA[\n{\"@type\":\"Thing\"}\n//]]></script>"},
+		{"block comment cdata", `<script type="application/ld+json">/*<![CDATA[*/{"@type":"Thing"}/*]]>*/</script>`},
+	}
+
+	for _, test := range testTable {
+		data := ParseData(test.script, t)
+		if len(data.Items) != 1 || !data.Items[0].IsOfType("Thing") {
+			t.Errorf("%s: Result should have been a Thing item, but it was %+v", test.name, data.Items)
+		}
+	}
+}
+
+func TestParseJSONLDSplitText(t *testing.T) {
+	tree, err := html.Parse(strings.NewReader(`<script type="application/ld+json"></script>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := findNode(tree, isJSONLDScript)
+	script.AppendChild(&html.Node{Type: html.TextNode, Data: `{"@type":"Thing",`})
+	script.AppendChild(&html.Node{Type: html.TextNode, Data: `"name":"Split"}`})
+
+	data, err := ParseNode(tree, "https://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, _ := data.Items[0].GetProperty("name"); name != "Split" {
+		t.Errorf("Result should have been \"Split\", but it was \"%v\"", name)
+	}
+}
+
 // This HTML snippet is taken from the W3C Working Group website at https://html.spec.whatwg.org/multipage/microdata.html#global-identifiers-for-items
 var bookSnippet = `
 <dl itemscope
@@ -0,0 +1,733 @@
+package microdata
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Microformats holds the microformats2 data of a document in the canonical JSON structure, see
+// https://microformats.org/wiki/microformats2-parsing.
+type Microformats struct {
+	Items   []*MicroformatItem  `json:"items"`
+	Rels    map[string][]string `json:"rels"`
+	RelURLs map[string]*RelURL  `json:"rel-urls"`
+}
+
+// MicroformatItem is a microformat, e.g. an h-card or an h-entry. Property values are strings, *MicroformatHTML
+// for e-* properties, *MicroformatImage for images with an alt text and *MicroformatItem for nested microformats.
+type MicroformatItem struct {
+	Type       []string                 `json:"type"`
+	Properties map[string][]interface{} `json:"properties"`
+	ID         string                   `json:"id,omitempty"`
+	Children   []*MicroformatItem       `json:"children,omitempty"`
+	// Value is the value of a microformat nested as a property value, HTML its HTML for an e-* property.
+	Value string `json:"value,omitempty"`
+	HTML  string `json:"html,omitempty"`
+}
+
+// MicroformatHTML is the value of an e-* property.
+type MicroformatHTML struct {
+	HTML  string `json:"html"`
+	Value string `json:"value"`
+}
+
+// MicroformatImage is the value of an image property with an alt text.
+type MicroformatImage struct {
+	Value string `json:"value"`
+	Alt   string `json:"alt"`
+}
+
+// RelURL describes a URL linked with a rel attribute.
+type RelURL struct {
+	Rels     []string `json:"rels"`
+	Text     string   `json:"text,omitempty"`
+	HrefLang string   `json:"hreflang,omitempty"`
+	Media    string   `json:"media,omitempty"`
+	Title    string   `json:"title,omitempty"`
+	Type     string   `json:"type,omitempty"`
+}
+
+// Item returns the microformat as an item. Its types are the microformat types, e.g. "h-entry", and its
+// properties the microformat properties. Nested microformats become nested items, children are added as
+// "children" property values.
+func (m *MicroformatItem) Item() *Item {
+	item := NewItem()
+	for _, t := range m.Type {
+		item.addType(t)
+	}
+
+	names := make([]string, 0, len(m.Properties))
+	for name := range m.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, v := range m.Properties[name] {
+			switch v := v.(type) {
+			case string:
+				item.addProperty(name, v)
+			case *MicroformatHTML:
+				item.addPropertyWithHTML(name, v.Value, v.HTML)
+			case *MicroformatImage:
+				item.addProperty(name, v.Value)
+			case *MicroformatItem:
+				item.addItem(name, v.Item())
+			}
+		}
+	}
+	for _, child := range m.Children {
+		item.addItem("children", child.Item())
+	}
+	return item
+}
+
+// add appends a value to the property.
+func (m *MicroformatItem) add(name string, value interface{}) {
+	m.Properties[name] = append(m.Properties[name], value)
+}
+
+// first returns the first value of the property if it is a string.
+func (m *MicroformatItem) first(name string) (string, bool) {
+	if values := m.Properties[name]; len(values) > 0 {
+		s, ok := values[0].(string)
+		return s, ok
+	}
+	return "", false
+}
+
+var (
+	mfRootClass     = regexp.MustCompile(`^h-([a-z0-9]+-)?[a-z]+(-[a-z]+)*$`)
+	mfPropertyClass = regexp.MustCompile(`^(p|u|dt|e)-(([a-z0-9]+-)?[a-z]+(-[a-z]+)*)$`)
+)
+
+// mfProperty is a property class name, e.g. "dt-published" has the prefix "dt" and the name "published".
+type mfProperty struct {
+	prefix, name string
+}
+
+// mfParser parses the microformats of a node tree.
+type mfParser struct {
+	base *url.URL
+}
+
+// mfItemState records what was found while parsing the properties of a microformat, for the implied properties.
+type mfItemState struct {
+	hasP, hasU, hasE, hasNested bool
+	// date is the date of the last dt-* property, used to complete dt-* properties holding a time only.
+	date string
+	// backcompat maps the classic property class names to their properties when parsing a classic microformat.
+	backcompat map[string]mfProperty
+}
+
+// readMicroformats returns the microformats of the parser's node tree, or nil if it has no microformats and no
+// rel links.
+func (p *parser) readMicroformats() *Microformats {
+	mp := &mfParser{base: p.baseURL}
+	mf := &Microformats{
+		Items:   []*MicroformatItem{},
+		Rels:    make(map[string][]string),
+		RelURLs: make(map[string]*RelURL),
+	}
+	mp.findItems(p.tree, &mf.Items)
+	mp.readRels(p.tree, mf)
+
+	if len(mf.Items) == 0 && len(mf.Rels) == 0 {
+		return nil
+	}
+	return mf
+}
+
+// findItems adds the top-level microformats found in the node tree to items.
+func (mp *mfParser) findItems(n *html.Node, items *[]*MicroformatItem) {
+	if n.Type == html.ElementNode {
+		if types, classic := mp.root(n); len(types) > 0 {
+			*items = append(*items, mp.parseItem(n, types, classic))
+			return
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		mp.findItems(c, items)
+	}
+}
+
+// root returns the microformats2 types of the element and whether they were mapped from classic microformats root
+// class names, which are only used when the element has no microformats2 root class names.
+func (mp *mfParser) root(n *html.Node) ([]string, bool) {
+	if types := mp.rootTypes(n); len(types) > 0 {
+		return types, false
+	}
+	types := backcompatTypes(n)
+	return types, len(types) > 0
+}
+
+// rootTypes returns the sorted, de-duplicated root class names of the element, e.g. "h-card".
+func (mp *mfParser) rootTypes(n *html.Node) []string {
+	var types []string
+	for _, class := range classNames(n) {
+		if mfRootClass.MatchString(class) && !containsString(types, class) {
+			types = append(types, class)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// isRoot returns true if the element is the root of a microformat.
+func (mp *mfParser) isRoot(n *html.Node) bool {
+	types, _ := mp.root(n)
+	return len(types) > 0
+}
+
+// properties returns the property class names of the element.
+func (mp *mfParser) properties(n *html.Node) []mfProperty {
+	var props []mfProperty
+	for _, class := range classNames(n) {
+		if m := mfPropertyClass.FindStringSubmatch(class); m != nil {
+			prop := mfProperty{prefix: m[1], name: m[2]}
+			if !containsProperty(props, prop) {
+				props = append(props, prop)
+			}
+		}
+	}
+	return props
+}
+
+// parseItem parses the microformat rooted at the given element. Classic microformats only have the properties of
+// their classic property class names and no implied properties.
+func (mp *mfParser) parseItem(n *html.Node, types []string, classic bool) *MicroformatItem {
+	item := &MicroformatItem{Type: types, Properties: make(map[string][]interface{})}
+	if id, ok := getAttr("id", n); ok && id != "" {
+		item.ID = id
+	}
+
+	state := &mfItemState{}
+	if classic {
+		state.backcompat = backcompatMapping(types)
+	}
+	mp.parseChildren(n, item, state)
+	if !classic {
+		mp.implyProperties(n, item, state)
+	}
+	return item
+}
+
+// propertiesOf returns the properties of the element within the microformat being parsed.
+func (mp *mfParser) propertiesOf(n *html.Node, state *mfItemState) []mfProperty {
+	if state.backcompat != nil {
+		return backcompatPropertiesOf(n, state.backcompat)
+	}
+	return mp.properties(n)
+}
+
+// parseChildren adds the properties and nested microformats found in the descendants of the element to the item.
+func (mp *mfParser) parseChildren(n *html.Node, item *MicroformatItem, state *mfItemState) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+
+		props := mp.propertiesOf(c, state)
+		if types, classic := mp.root(c); len(types) > 0 {
+			nested := mp.parseItem(c, types, classic)
+			state.hasNested = true
+			if len(props) == 0 {
+				item.Children = append(item.Children, nested)
+				continue
+			}
+
+			for _, prop := range props {
+				value := *nested
+				switch prop.prefix {
+				case "p", "dt":
+					if name, ok := nested.first("name"); ok {
+						value.Value = name
+					} else {
+						value.Value = mp.text(c)
+					}
+				case "u":
+					if u, ok := nested.first("url"); ok {
+						value.Value = u
+					} else {
+						value.Value = mp.urlValue(c)
+					}
+				case "e":
+					value.HTML = innerHTML(c)
+					value.Value = mp.text(c)
+				}
+				mp.record(state, prop)
+				item.add(prop.name, &value)
+			}
+			continue
+		}
+
+		for _, prop := range props {
+			mp.record(state, prop)
+			item.add(prop.name, mp.propertyValue(c, prop.prefix, state))
+		}
+		mp.parseChildren(c, item, state)
+	}
+}
+
+// record records the prefix of a property found.
+func (mp *mfParser) record(state *mfItemState, prop mfProperty) {
+	switch prop.prefix {
+	case "p":
+		state.hasP = true
+	case "u":
+		state.hasU = true
+	case "e":
+		state.hasE = true
+	}
+}
+
+// propertyValue returns the value of the property with the given prefix found on the element.
+func (mp *mfParser) propertyValue(n *html.Node, prefix string, state *mfItemState) interface{} {
+	switch prefix {
+	case "u":
+		if n.DataAtom == atom.Img {
+			if src, ok := getAttr("src", n); ok {
+				return mp.image(n, src)
+			}
+		}
+		return mp.urlValue(n)
+	case "dt":
+		return mp.dateValue(n, state)
+	case "e":
+		return &MicroformatHTML{HTML: strings.TrimSpace(innerHTML(n)), Value: mp.text(n)}
+	}
+
+	if rel, _ := getAttr("rel", n); state.backcompat != nil && containsToken(rel, "tag") {
+		return mp.relTagValue(n)
+	}
+
+	if v, ok := mp.valueClass(n); ok {
+		return strings.Join(v, "")
+	}
+	switch n.DataAtom {
+	case atom.Abbr, atom.Link:
+		if v, ok := getAttr("title", n); ok {
+			return v
+		}
+	case atom.Data, atom.Input:
+		if v, ok := getAttr("value", n); ok {
+			return v
+		}
+	case atom.Img, atom.Area:
+		if v, ok := getAttr("alt", n); ok {
+			return v
+		}
+	}
+	return mp.text(n)
+}
+
+// urlValue returns the value of a u-* property.
+func (mp *mfParser) urlValue(n *html.Node) string {
+	var attr string
+	switch n.DataAtom {
+	case atom.A, atom.Area, atom.Link:
+		attr = "href"
+	case atom.Img, atom.Audio, atom.Source, atom.Iframe:
+		attr = "src"
+	case atom.Video:
+		attr = "src"
+		if _, ok := getAttr("src", n); !ok {
+			attr = "poster"
+		}
+	case atom.Object:
+		attr = "data"
+	}
+	if attr != "" {
+		if v, ok := getAttr(attr, n); ok {
+			return mp.resolve(v)
+		}
+	}
+
+	if v, ok := mp.valueClass(n); ok {
+		return mp.resolve(strings.Join(v, ""))
+	}
+	switch n.DataAtom {
+	case atom.Abbr:
+		if v, ok := getAttr("title", n); ok {
+			return mp.resolve(v)
+		}
+	case atom.Data, atom.Input:
+		if v, ok := getAttr("value", n); ok {
+			return mp.resolve(v)
+		}
+	}
+	return mp.resolve(mp.text(n))
+}
+
+// dateValue returns the value of a dt-* property, combining the date and time parts of the value class pattern.
+func (mp *mfParser) dateValue(n *html.Node, state *mfItemState) string {
+	var value string
+	if parts, ok := mp.valueClass(n); ok {
+		value = combineDateTime(parts)
+	} else {
+		switch n.DataAtom {
+		case atom.Time, atom.Ins, atom.Del:
+			if v, ok := getAttr("datetime", n); ok {
+				value = v
+			}
+		case atom.Abbr:
+			if v, ok := getAttr("title", n); ok {
+				value = v
+			}
+		case atom.Data, atom.Input:
+			if v, ok := getAttr("value", n); ok {
+				value = v
+			}
+		}
+		if value == "" {
+			value = mp.text(n)
+		}
+	}
+	value = strings.TrimSpace(value)
+
+	// A time only value takes the date of an earlier dt-* property of the microformat.
+	if mfTimePattern.MatchString(value) {
+		if state.date != "" {
+			value = state.date + " " + normalizeTime(value)
+		}
+	} else if m := mfDatePrefix.FindString(value); m != "" {
+		state.date = m
+	}
+	return value
+}
+
+var (
+	mfDatePattern = regexp.MustCompile(`^\d{4}-(\d{2}-\d{2}|\d{3})$`)
+	mfDatePrefix  = regexp.MustCompile(`^\d{4}-(\d{2}-\d{2}|\d{3})`)
+	mfTimePattern = regexp.MustCompile(`(?i)^\d{1,2}(:\d{2}(:\d{2})?)?\s*([ap]\.?m\.?)?(Z|[+-]\d{1,2}:?\d{2})?$`)
+	mfZonePattern = regexp.MustCompile(`^(Z|[+-]\d{1,2}:?\d{2})$`)
+)
+
+// combineDateTime returns the date time of the value class pattern parts: the first date, time and timezone.
+func combineDateTime(parts []string) string {
+	var date, clock, zone string
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		switch {
+		case mfDatePattern.MatchString(part):
+			if date == "" {
+				date = part
+			}
+		case mfZonePattern.MatchString(part):
+			if zone == "" {
+				zone = part
+			}
+		case mfTimePattern.MatchString(part):
+			if clock == "" {
+				clock = normalizeTime(part)
+			}
+		default:
+			if date == "" && clock == "" {
+				// A complete date time in a single part.
+				return part
+			}
+		}
+	}
+
+	switch {
+	case date != "" && clock != "":
+		return date + " " + clock + zone
+	case date != "":
+		return date
+	}
+	return clock + zone
+}
+
+// normalizeTime converts a time with an am/pm suffix to the 24-hour clock, e.g. "7pm" to "19:00".
+func normalizeTime(s string) string {
+	lower := strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(s, ".", ""), " ", ""))
+	pm := strings.HasSuffix(lower, "pm")
+	if !pm && !strings.HasSuffix(lower, "am") {
+		return s
+	}
+
+	parts := strings.Split(lower[:len(lower)-2], ":")
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return s
+	}
+	if pm && hour < 12 {
+		hour += 12
+	} else if !pm && hour == 12 {
+		hour = 0
+	}
+	parts[0] = leftPad(strconv.Itoa(hour), 2)
+	if len(parts) == 1 {
+		parts = append(parts, "00")
+	}
+	return strings.Join(parts, ":")
+}
+
+// valueClass returns the values of the value class pattern: the descendants with the "value" or "value-title"
+// class, outside of nested properties and microformats.
+func (mp *mfParser) valueClass(n *html.Node) ([]string, bool) {
+	var values []string
+	found := false
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			classes := classNames(c)
+			switch {
+			case containsString(classes, "value-title"):
+				found = true
+				if v, ok := getAttr("title", c); ok {
+					values = append(values, v)
+				}
+			case containsString(classes, "value"):
+				found = true
+				values = append(values, mp.valueClassValue(c))
+			case mp.isRoot(c) || len(mp.properties(c)) > 0:
+			default:
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return values, found
+}
+
+// valueClassValue returns the value of an element with the "value" class.
+func (mp *mfParser) valueClassValue(n *html.Node) string {
+	switch n.DataAtom {
+	case atom.Img, atom.Area:
+		if v, ok := getAttr("alt", n); ok {
+			return v
+		}
+	case atom.Data:
+		if v, ok := getAttr("value", n); ok {
+			return v
+		}
+	case atom.Abbr:
+		if v, ok := getAttr("title", n); ok {
+			return v
+		}
+	}
+	return textContent(n)
+}
+
+// implyProperties adds the implied name, photo and url properties to the item.
+func (mp *mfParser) implyProperties(n *html.Node, item *MicroformatItem, state *mfItemState) {
+	if _, ok := item.Properties["name"]; !ok && !state.hasP && !state.hasE && !state.hasNested {
+		item.add("name", mp.impliedName(n))
+	}
+
+	if _, ok := item.Properties["photo"]; !ok && !state.hasU && !state.hasNested {
+		if photo := mp.impliedPhoto(n); photo != nil {
+			item.add("photo", photo)
+		}
+	}
+
+	if _, ok := item.Properties["url"]; !ok && !state.hasU && !state.hasNested {
+		if el := mp.impliedElement(n, func(n *html.Node) bool {
+			_, ok := getAttr("href", n)
+			return ok && (n.DataAtom == atom.A || n.DataAtom == atom.Area)
+		}); el != nil {
+			href, _ := getAttr("href", el)
+			item.add("url", mp.resolve(href))
+		}
+	}
+}
+
+// impliedName returns the implied name of the microformat rooted at the element.
+func (mp *mfParser) impliedName(n *html.Node) string {
+	named := func(n *html.Node) (string, bool) {
+		switch n.DataAtom {
+		case atom.Img, atom.Area:
+			return getAttr("alt", n)
+		case atom.Abbr:
+			return getAttr("title", n)
+		}
+		return "", false
+	}
+
+	if v, ok := named(n); ok {
+		return v
+	}
+	for _, c := range []*html.Node{mp.onlyChild(n), mp.onlyChild(mp.onlyChild(n))} {
+		if c == nil || mp.isRoot(c) {
+			break
+		}
+		if v, ok := named(c); ok {
+			return v
+		}
+	}
+	return mp.text(n)
+}
+
+// impliedPhoto returns the implied photo of the microformat rooted at the element, or nil.
+func (mp *mfParser) impliedPhoto(n *html.Node) interface{} {
+	el := mp.impliedElement(n, func(n *html.Node) bool {
+		if n.DataAtom == atom.Img {
+			_, ok := getAttr("src", n)
+			return ok
+		}
+		if n.DataAtom == atom.Object {
+			_, ok := getAttr("data", n)
+			return ok
+		}
+		return false
+	})
+	if el == nil {
+		return nil
+	}
+	if el.DataAtom == atom.Object {
+		data, _ := getAttr("data", el)
+		return mp.resolve(data)
+	}
+	src, _ := getAttr("src", el)
+	return mp.image(el, src)
+}
+
+// impliedElement returns the element itself, its only child or the only child of its only child, none of them a
+// microformat, that matches the given function.
+func (mp *mfParser) impliedElement(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if match(n) {
+		return n
+	}
+	c := mp.onlyChild(n)
+	for i := 0; i < 2 && c != nil && !mp.isRoot(c); i++ {
+		if match(c) {
+			return c
+		}
+		c = mp.onlyChild(c)
+	}
+	return nil
+}
+
+// onlyChild returns the only child element of the element, or nil.
+func (mp *mfParser) onlyChild(n *html.Node) *html.Node {
+	if n == nil {
+		return nil
+	}
+	var only *html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		if only != nil {
+			return nil
+		}
+		only = c
+	}
+	return only
+}
+
+// image returns the value of an image: a *MicroformatImage when it has an alt text, the resolved URL otherwise.
+func (mp *mfParser) image(n *html.Node, src string) interface{} {
+	if alt, ok := getAttr("alt", n); ok {
+		return &MicroformatImage{Value: mp.resolve(src), Alt: alt}
+	}
+	return mp.resolve(src)
+}
+
+// text returns the trimmed text content of the element without scripts and styles, with images replaced by their
+// alt text.
+func (mp *mfParser) text(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch {
+			case c.Type == html.TextNode:
+				b.WriteString(c.Data)
+			case c.Type != html.ElementNode:
+			case c.DataAtom == atom.Script || c.DataAtom == atom.Style || c.DataAtom == atom.Template:
+			case c.DataAtom == atom.Img:
+				if alt, ok := getAttr("alt", c); ok {
+					b.WriteString(" " + alt + " ")
+				}
+			default:
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return strings.TrimSpace(b.String())
+}
+
+// resolve returns the URL resolved against the base URL.
+func (mp *mfParser) resolve(s string) string {
+	s = strings.TrimSpace(s)
+	if u, err := mp.base.Parse(s); err == nil {
+		return u.String()
+	}
+	return s
+}
+
+// readRels adds the rel links of the document to the microformats.
+func (mp *mfParser) readRels(root *html.Node, mf *Microformats) {
+	walkNodes(root, func(n *html.Node) {
+		if n.Type != html.ElementNode || (n.DataAtom != atom.A && n.DataAtom != atom.Area && n.DataAtom != atom.Link) {
+			return
+		}
+		rel, ok := getAttr("rel", n)
+		href, hasHref := getAttr("href", n)
+		if !ok || !hasHref || len(strings.Fields(rel)) == 0 {
+			return
+		}
+
+		u := mp.resolve(href)
+		relURL, ok := mf.RelURLs[u]
+		if !ok {
+			relURL = &RelURL{}
+			if n.DataAtom != atom.Link {
+				relURL.Text = mp.text(n)
+			}
+			relURL.HrefLang, _ = getAttr("hreflang", n)
+			relURL.Media, _ = getAttr("media", n)
+			relURL.Title, _ = getAttr("title", n)
+			relURL.Type, _ = getAttr("type", n)
+			mf.RelURLs[u] = relURL
+		}
+
+		for _, r := range strings.Fields(rel) {
+			r = strings.ToLower(r)
+			if !containsString(relURL.Rels, r) {
+				relURL.Rels = append(relURL.Rels, r)
+			}
+			if !containsString(mf.Rels[r], u) {
+				mf.Rels[r] = append(mf.Rels[r], u)
+			}
+		}
+	})
+}
+
+// classNames returns the class names of the element.
+func classNames(n *html.Node) []string {
+	class, _ := getAttr("class", n)
+	return strings.Fields(class)
+}
+
+// containsString returns true if the list contains the string.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// containsProperty returns true if the list contains the property.
+func containsProperty(list []mfProperty, prop mfProperty) bool {
+	for _, v := range list {
+		if v == prop {
+			return true
+		}
+	}
+	return false
+}
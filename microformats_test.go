@@ -0,0 +1,98 @@
+package microdata
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseMicroformats(t *testing.T) {
+	html := `<html><head><link rel="me authn" href="https://github.com/jane"></head><body>
+		<article class="h-entry" id="post">
+			<h1 class="p-name">Hello world</h1>
+			<a class="u-url" href="/2024/hello">Permalink</a>
+			<span class="dt-published"><span class="value">2024-03-01</span> at <span class="value">7pm</span></span>
+			<time class="dt-updated" datetime="21:30">later</time>
+			<div class="p-author h-card"><img class="u-photo" src="/jane.jpg" alt="Jane"><a class="p-name u-url" href="/">Jane Doe</a></div>
+			<div class="e-content"><p>First <b>post</b>.</p></div>
+			<a class="p-category" href="/tags/go" rel="tag">go</a>
+		</article>
+		<a class="h-card" href="https://example.org/"><img src="/logo.png" alt="Example Org"></a>
+		<div class="h-event"><div class="h-card">Nested child</div></div>
+	</body></html>`
+
+	data, err := ParseHTML(strings.NewReader(html), "text/html; charset=utf-8", "https://jane.example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Items) != 0 {
+		t.Errorf("Result should have been 0 items, but it was %d", len(data.Items))
+	}
+
+	b, err := json.Marshal(data.Microformats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(b)
+	expected := `{"items":[{"type":["h-entry"],"properties":{"author":[{"type":["h-card"],"properties":{"name":["Jane Doe"],"photo":[{"value":"https://jane.example.com/jane.jpg","alt":"Jane"}],"url":["https://jane.example.com/"]},"value":"Jane Doe"}],"category":["go"],"content":[{"html":"\u003cp\u003eFirst \u003cb\u003epost\u003c/b\u003e.\u003c/p\u003e","value":"First post."}],"name":["Hello world"],"published":["2024-03-01 19:00"],"updated":["2024-03-01 21:30"],"url":["https://jane.example.com/2024/hello"]},"id":"post"},{"type":["h-card"],"properties":{"name":["Example Org"],"photo":[{"value":"https://jane.example.com/logo.png","alt":"Example Org"}],"url":["https://example.org/"]}},{"type":["h-event"],"properties":{},"children":[{"type":["h-card"],"properties":{"name":["Nested child"]}}]}],"rels":{"authn":["https://github.com/jane"],"me":["https://github.com/jane"],"tag":["https://jane.example.com/tags/go"]},"rel-urls":{"https://github.com/jane":{"rels":["me","authn"]},"https://jane.example.com/tags/go":{"rels":["tag"],"text":"go"}}}`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
+
+func TestParseMicroformatItems(t *testing.T) {
+	html := `<div class="h-card"><a class="p-name u-url" href="/jane">Jane</a><p class="p-org h-card">ACME</p></div>`
+
+	data, err := ParseHTML(strings.NewReader(html), "text/html; charset=utf-8", "https://example.com/", WithMicroformatItems())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(data.Items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(b)
+	expected := `[{"type":["h-card"],"properties":{"name":["Jane"],"org":[{"type":["h-card"],"properties":{"name":["ACME"]}}],"url":["https://example.com/jane"]}}]`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+
+	if data.GetFirstOfType("h-card") == nil {
+		t.Error("Result should have been found by GetFirstOfType")
+	}
+}
+
+func TestParseMicroformatsBackcompat(t *testing.T) {
+	html := `
+		<div class="vcard">
+			<a class="url fn org" href="/">Joe's Pizza</a>
+			<div class="adr"><span class="street-address">1 Main St</span>, <span class="locality">Springfield</span></div>
+			<span class="tel">555-0100</span>
+			<img class="photo" src="/pizza.jpg" alt="Pizza">
+			<span class="p-ignored">mf2 properties are ignored in classic roots</span>
+		</div>
+		<div class="hreview">
+			<span class="summary">Great pizza</span>
+			<span class="reviewer vcard"><span class="fn">Ann</span></span>
+			<abbr class="dtreviewed" title="2024-05-01">May 1st</abbr>
+			<span class="rating">5</span>
+			<a rel="tag" href="/tags/italian/">Italian food</a>
+		</div>
+		<div class="h-card vcard"><span class="fn">Classic names are ignored in mf2 roots</span></div>`
+
+	data, err := ParseHTML(strings.NewReader(html), "text/html; charset=utf-8", "https://pizza.example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(data.Microformats.Items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(b)
+	expected := `[{"type":["h-card"],"properties":{"adr":[{"type":["h-adr"],"properties":{"locality":["Springfield"],"street-address":["1 Main St"]},"value":"1 Main St, Springfield"}],"name":["Joe's Pizza"],"org":["Joe's Pizza"],"photo":[{"value":"https://pizza.example.com/pizza.jpg","alt":"Pizza"}],"tel":["555-0100"],"url":["https://pizza.example.com/"]}},{"type":["h-review"],"properties":{"author":[{"type":["h-card"],"properties":{"name":["Ann"]},"value":"Ann"}],"category":["italian"],"name":["Great pizza"],"published":["2024-05-01"],"rating":["5"]}},{"type":["h-card"],"properties":{"name":["Classic names are ignored in mf2 roots"]}}]`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
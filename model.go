@@ -1,11 +1,46 @@
 package microdata
 
 import (
+	"encoding/json"
 	"fmt"
 )
 
 type Microdata struct {
 	Items []*Item `json:"items"`
+	// Metadata holds the title, description and other page-level metadata of the document, nil when it has none.
+	Metadata *DocumentMetadata `json:"metadata,omitempty"`
+	// OpenGraph holds the Open Graph metadata of the document, nil when it has none.
+	OpenGraph *OpenGraph `json:"openGraph,omitempty"`
+	// Twitter holds the Twitter card metadata of the document, nil when it has none.
+	Twitter *TwitterCard `json:"twitter,omitempty"`
+	// Microformats holds the microformats2 items and rel links of the document, nil when it has none.
+	Microformats *Microformats `json:"microformats,omitempty"`
+	// JSONLD holds the expanded JSON-LD of the document, when parsed WithJSONLDExpansion.
+	JSONLD []interface{} `json:"jsonld,omitempty"`
+	// OEmbed holds the oEmbed endpoints of the document, see OEmbedResolver to fetch them.
+	OEmbed []OEmbedEndpoint `json:"oembed,omitempty"`
+	// BaseURL is the URL the URLs in the document are resolved against: the document URL or, when the document has
+	// a <base href> element, its resolved href.
+	BaseURL string `json:"-"`
+	// Encoding is the character encoding the document was decoded with. It is only set by ParseHTML.
+	Encoding *Encoding `json:"-"`
+	// metadataJSON tells MarshalJSON to write the page-level metadata, see WithMetadataJSON.
+	metadataJSON bool
+	// scripts counts the JSON-LD scripts the items were read from, see Item.script.
+	scripts int
+}
+
+// MarshalJSON returns the items as JSON, with the expanded JSON-LD if any. The page-level metadata, i.e. Metadata,
+// OpenGraph, Twitter, Microformats and OEmbed, is written too when the document was parsed WithMetadataJSON.
+func (m Microdata) MarshalJSON() ([]byte, error) {
+	if m.metadataJSON {
+		type microdata Microdata
+		return json.Marshal(microdata(m))
+	}
+	return json.Marshal(struct {
+		Items  []*Item       `json:"items"`
+		JSONLD []interface{} `json:"jsonld,omitempty"`
+	}{m.Items, m.JSONLD})
 }
 
 // addItem adds the item to the items list.
@@ -20,7 +55,18 @@ func (m *Microdata) GetFirstOfSchemaType(itemType string) *Item {
 
 // GetFirstOfType returns the first item of the given type.
 func (m *Microdata) GetFirstOfType(itemType ...string) *Item {
+	return m.getFirstOfType(itemType, make(map[*Item]bool))
+}
+
+// getFirstOfType returns the first item of the given type, skipping the visited items, as the @graph arrays of
+// flattened items can form cycles.
+func (m *Microdata) getFirstOfType(itemType []string, visited map[*Item]bool) *Item {
 	for _, item := range m.Items {
+		if visited[item] {
+			continue
+		}
+		visited[item] = true
+
 		for _, t1 := range item.Types {
 			for _, t2 := range itemType {
 				if t1 == t2 {
@@ -30,7 +76,7 @@ func (m *Microdata) GetFirstOfType(itemType ...string) *Item {
 		}
 
 		if graph, ok := item.GetNested("@graph"); ok {
-			if item := graph.GetFirstOfType(itemType...); item != nil {
+			if item := graph.getFirstOfType(itemType, visited); item != nil {
 				return item
 			}
 		}
@@ -48,6 +94,57 @@ type Item struct {
 	Properties PropertyMap         `json:"properties"`
 	InnerHTML  map[string][]string `json:"innerHTML,omitempty"` // Raw HTML content for text-based properties
 	ID         string              `json:"id,omitempty"`
+	// Origin tells where an item was found when it is not part of the document's markup, e.g. OriginNextData.
+	Origin string `json:"origin,omitempty"`
+	// script numbers the JSON-LD script a top-level item was read from, from 1, as its blank node IDs are local to
+	// it. It is 0 for other items.
+	script int
+	// urls holds the values of the properties that were read from URL property elements, e.g. the href of <a>.
+	urls map[string]map[string]bool
+}
+
+// itemJSON is the JSON form of an Item, see Item.MarshalJSON.
+type itemJSON struct {
+	Types      []string                 `json:"type"`
+	Properties map[string][]interface{} `json:"properties"`
+	InnerHTML  map[string][]string      `json:"innerHTML,omitempty"`
+	ID         string                   `json:"id,omitempty"`
+	Origin     string                   `json:"origin,omitempty"`
+}
+
+// MarshalJSON returns the item as JSON. The items of a flattened document can form cycles, see Microdata.Flatten:
+// an item nested in itself is written as a reference, with its ID only.
+func (i *Item) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.toJSON(make(map[*Item]bool)))
+}
+
+// toJSON returns the JSON form of the item. Items already in path are written as references, to break cycles.
+func (i *Item) toJSON(path map[*Item]bool) *itemJSON {
+	if path[i] {
+		return &itemJSON{Types: []string{}, Properties: map[string][]interface{}{}, ID: i.ID}
+	}
+	path[i] = true
+	defer delete(path, i)
+
+	j := &itemJSON{Types: i.Types, InnerHTML: i.InnerHTML, ID: i.ID, Origin: i.Origin}
+	if i.Properties != nil {
+		j.Properties = make(map[string][]interface{}, len(i.Properties))
+	}
+	for key, values := range i.Properties {
+		if values == nil {
+			j.Properties[key] = nil
+			continue
+		}
+		a := make([]interface{}, len(values))
+		for k, v := range values {
+			if nested, ok := v.(*Item); ok && nested != nil {
+				v = nested.toJSON(path)
+			}
+			a[k] = v
+		}
+		j.Properties[key] = a
+	}
+	return j
 }
 
 // addType adds the value to the types list.
@@ -66,6 +163,23 @@ func (i *Item) addPropertyWithHTML(key string, value interface{}, innerHTML stri
 	i.InnerHTML[key] = append(i.InnerHTML[key], innerHTML)
 }
 
+// addURL marks the value of the property as a URL, see Item.isURL.
+func (i *Item) addURL(key, value string) {
+	if i.urls == nil {
+		i.urls = make(map[string]map[string]bool)
+	}
+	if i.urls[key] == nil {
+		i.urls[key] = make(map[string]bool)
+	}
+	i.urls[key][value] = true
+}
+
+// isURL returns true if the value of the property was read from a URL property element, e.g. the href of <a>.
+func (i *Item) isURL(key string, value interface{}) bool {
+	s, ok := value.(string)
+	return ok && i.urls[key][s]
+}
+
 // addItem adds the property, value pair to the properties map. It appends to any existing property.
 func (i *Item) addItem(key string, value *Item) {
 	i.Properties[key] = append(i.Properties[key], value)
@@ -131,13 +245,23 @@ func (i *Item) GetNested(keys ...string) (data Microdata, ok bool) {
 }
 
 func (i *Item) CountPaths(prefix string, paths *map[string]int) {
+	i.countPaths(prefix, paths, make(map[*Item]bool))
+}
+
+// countPaths counts the paths of the item. Items already in path aren't walked again, to break cycles.
+func (i *Item) countPaths(prefix string, paths *map[string]int, path map[*Item]bool) {
+	path[i] = true
+	defer delete(path, i)
+
 	for key, val := range i.Properties {
 		(*paths)[fmt.Sprintf("%s[%T]", prefix+key, val[0])]++
 
 		for _, vv := range val {
 			switch vv.(type) {
 			case *Item:
-				vv.(*Item).CountPaths(prefix+key+".", paths)
+				if !path[vv.(*Item)] {
+					vv.(*Item).countPaths(prefix+key+".", paths, path)
+				}
 			}
 		}
 	}
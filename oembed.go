@@ -0,0 +1,294 @@
+package microdata
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// OriginOEmbed marks items resolved from the oEmbed endpoint of a document.
+const OriginOEmbed = "oembed"
+
+// Formats of oEmbed endpoints.
+const (
+	OEmbedJSON = "json"
+	OEmbedXML  = "xml"
+)
+
+// OEmbedEndpoint is an oEmbed endpoint discovered in a <link rel="alternate" type="application/json+oembed"> or
+// type="text/xml+oembed" element, see https://oembed.com/#section4.
+type OEmbedEndpoint struct {
+	// URL is the resolved href of the link, including the url and format query parameters set by the provider.
+	URL string `json:"url"`
+	// Format is OEmbedJSON or OEmbedXML.
+	Format string `json:"format"`
+	Title  string `json:"title,omitempty"`
+}
+
+// OEmbed is an oEmbed response, see https://oembed.com/#section2.3. Width and height are 0 when missing.
+type OEmbed struct {
+	// Type is one of "photo", "video", "link" and "rich".
+	Type            string `json:"type"`
+	Version         string `json:"version,omitempty"`
+	Title           string `json:"title,omitempty"`
+	AuthorName      string `json:"author_name,omitempty"`
+	AuthorURL       string `json:"author_url,omitempty"`
+	ProviderName    string `json:"provider_name,omitempty"`
+	ProviderURL     string `json:"provider_url,omitempty"`
+	CacheAge        int    `json:"cache_age,omitempty"`
+	ThumbnailURL    string `json:"thumbnail_url,omitempty"`
+	ThumbnailWidth  int    `json:"thumbnail_width,omitempty"`
+	ThumbnailHeight int    `json:"thumbnail_height,omitempty"`
+	// URL is the source URL of a photo.
+	URL string `json:"url,omitempty"`
+	// HTML is the embed markup of a video or rich content.
+	HTML   string `json:"html,omitempty"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+// Item returns the oEmbed response as a schema.org item: a VideoObject for a video, an ImageObject for a photo and
+// a CreativeWork otherwise. The embed markup is the "embedHtml" property.
+func (o *OEmbed) Item() *Item {
+	item := NewItem()
+	item.Origin = OriginOEmbed
+
+	switch o.Type {
+	case "video":
+		item.addType("https://schema.org/VideoObject")
+	case "photo":
+		item.addType("https://schema.org/ImageObject")
+		if o.URL != "" {
+			item.addProperty("contentUrl", o.URL)
+		}
+	default:
+		item.addType("https://schema.org/CreativeWork")
+	}
+
+	if o.Title != "" {
+		item.addProperty("name", o.Title)
+	}
+	if o.AuthorName != "" || o.AuthorURL != "" {
+		item.addItem("author", oembedAgent("https://schema.org/Person", o.AuthorName, o.AuthorURL))
+	}
+	if o.ProviderName != "" || o.ProviderURL != "" {
+		item.addItem("provider", oembedAgent("https://schema.org/Organization", o.ProviderName, o.ProviderURL))
+	}
+	if o.ThumbnailURL != "" {
+		item.addProperty("thumbnailUrl", o.ThumbnailURL)
+	}
+	if o.Width > 0 {
+		item.addProperty("width", o.Width)
+	}
+	if o.Height > 0 {
+		item.addProperty("height", o.Height)
+	}
+	if o.HTML != "" {
+		item.addProperty("embedHtml", o.HTML)
+	}
+	return item
+}
+
+// oembedAgent returns an item of the given type with the name and url of an author or provider.
+func oembedAgent(typ, name, url string) *Item {
+	item := NewItem()
+	item.addType(typ)
+	if name != "" {
+		item.addProperty("name", name)
+	}
+	if url != "" {
+		item.addProperty("url", url)
+	}
+	return item
+}
+
+// oembedEndpoint returns the oEmbed endpoint of the given <link> element.
+func (p *parser) oembedEndpoint(n *html.Node) (OEmbedEndpoint, bool) {
+	if n.Type != html.ElementNode || n.DataAtom != atom.Link || n.Namespace != "" {
+		return OEmbedEndpoint{}, false
+	}
+	rel, _ := getAttr("rel", n)
+	typ, _ := getAttr("type", n)
+	href, ok := getAttr("href", n)
+	if !ok || strings.TrimSpace(href) == "" || !containsToken(rel, "alternate") {
+		return OEmbedEndpoint{}, false
+	}
+
+	var format string
+	mediaType, _, _ := mime.ParseMediaType(strings.TrimSpace(typ))
+	switch mediaType {
+	case "application/json+oembed":
+		format = OEmbedJSON
+	case "text/xml+oembed", "application/xml+oembed":
+		format = OEmbedXML
+	default:
+		return OEmbedEndpoint{}, false
+	}
+
+	title, _ := getAttr("title", n)
+	return OEmbedEndpoint{URL: p.resolveURL(href), Format: format, Title: strings.TrimSpace(title)}, true
+}
+
+// OEmbedResolver fetches the oEmbed endpoints discovered in documents. Resolution is opt-in: parsing only records
+// the endpoints in Microdata.OEmbed.
+type OEmbedResolver struct {
+	// Client is the HTTP client used for requests, a client with DefaultTimeout when nil.
+	Client *http.Client
+	// Header is added to every request.
+	Header http.Header
+	// MaxBodySize is the maximum number of body bytes read from a response. A value <= 0 disables the limit.
+	MaxBodySize int64
+}
+
+// NewOEmbedResolver returns an OEmbedResolver using the given HTTP client, or a client with DefaultTimeout when nil.
+func NewOEmbedResolver(client *http.Client) *OEmbedResolver {
+	if client == nil {
+		client = defaultClient
+	}
+	return &OEmbedResolver{
+		Client:      client,
+		Header:      http.Header{"User-Agent": {DefaultUserAgent}},
+		MaxBodySize: DefaultMaxBodySize,
+	}
+}
+
+// Resolve fetches the oEmbed response of the endpoint. All errors are of type *FetchError.
+func (r *OEmbedResolver) Resolve(ctx context.Context, endpoint OEmbedEndpoint) (*OEmbed, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.URL, nil)
+	if err != nil {
+		return nil, &FetchError{URL: endpoint.URL, Err: err}
+	}
+	for key, values := range r.Header {
+		req.Header[key] = values
+	}
+
+	client := r.Client
+	if client == nil {
+		client = defaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &FetchError{URL: endpoint.URL, Err: err}
+	}
+	defer resp.Body.Close()
+
+	fetchErr := func(err error) *FetchError {
+		return &FetchError{
+			URL:         resp.Request.URL.String(),
+			StatusCode:  resp.StatusCode,
+			ContentType: resp.Header.Get("Content-Type"),
+			Err:         err,
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fetchErr(ErrUnexpectedStatus)
+	}
+
+	body := resp.Body
+	if r.MaxBodySize > 0 {
+		body = readCloser{&limitedReader{r: resp.Body, n: r.MaxBodySize}, resp.Body}
+	}
+
+	var fields map[string]interface{}
+	if endpoint.Format == OEmbedXML {
+		fields, err = decodeOEmbedXML(body)
+	} else {
+		err = json.NewDecoder(body).Decode(&fields)
+	}
+	if err != nil {
+		return nil, fetchErr(err)
+	}
+	return newOEmbed(fields), nil
+}
+
+// AddItem resolves the first JSON endpoint of the document, or the first XML one when it has none, and adds the
+// response to its items. It returns the added item, or nil when the document has no oEmbed endpoint.
+func (r *OEmbedResolver) AddItem(ctx context.Context, data *Microdata) (*Item, error) {
+	var endpoint *OEmbedEndpoint
+	for i := range data.OEmbed {
+		if data.OEmbed[i].Format == OEmbedJSON {
+			endpoint = &data.OEmbed[i]
+			break
+		}
+		if endpoint == nil {
+			endpoint = &data.OEmbed[i]
+		}
+	}
+	if endpoint == nil {
+		return nil, nil
+	}
+
+	o, err := r.Resolve(ctx, *endpoint)
+	if err != nil {
+		return nil, err
+	}
+	item := o.Item()
+	data.addItem(item)
+	return item, nil
+}
+
+// decodeOEmbedXML returns the fields of an <oembed> XML response.
+func decodeOEmbedXML(r io.Reader) (map[string]interface{}, error) {
+	var doc struct {
+		XMLName xml.Name `xml:"oembed"`
+		Fields  []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	}
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]interface{}, len(doc.Fields))
+	for _, f := range doc.Fields {
+		fields[f.XMLName.Local] = f.Value
+	}
+	return fields, nil
+}
+
+// newOEmbed returns the oEmbed response of the decoded fields. Sizes may be given as numbers or strings.
+func newOEmbed(fields map[string]interface{}) *OEmbed {
+	str := func(key string) string {
+		switch v := fields[key].(type) {
+		case string:
+			return strings.TrimSpace(v)
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		return ""
+	}
+	num := func(key string) int {
+		f, err := strconv.ParseFloat(str(key), 64)
+		if err != nil {
+			return 0
+		}
+		return int(f)
+	}
+
+	return &OEmbed{
+		Type:            str("type"),
+		Version:         str("version"),
+		Title:           str("title"),
+		AuthorName:      str("author_name"),
+		AuthorURL:       str("author_url"),
+		ProviderName:    str("provider_name"),
+		ProviderURL:     str("provider_url"),
+		CacheAge:        num("cache_age"),
+		ThumbnailURL:    str("thumbnail_url"),
+		ThumbnailWidth:  num("thumbnail_width"),
+		ThumbnailHeight: num("thumbnail_height"),
+		URL:             str("url"),
+		HTML:            str("html"),
+		Width:           num("width"),
+		Height:          num("height"),
+	}
+}
@@ -0,0 +1,112 @@
+package microdata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseOEmbedEndpoints(t *testing.T) {
+	html := `<html><head>
+		<link rel="alternate" type="text/xml+oembed" href="/oembed?format=xml" title="Video">
+		<link rel="alternate" type="Application/JSON+oEmbed; charset=utf-8" href="/oembed?format=json">
+		<link rel="alternate" type="application/rss+xml" href="/feed">
+		<link rel="stylesheet" type="application/json+oembed" href="/not-an-endpoint">
+	</head></html>`
+
+	data := ParseData(html, t)
+
+	b, err := json.Marshal(data.OEmbed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(b)
+	expected := `[{"url":"https://example.com/oembed?format=xml","format":"xml","title":"Video"},{"url":"https://example.com/oembed?format=json","format":"json"}]`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
+
+func TestOEmbedResolverAddItem(t *testing.T) {
+	var accept string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept")
+		switch r.URL.Query().Get("format") {
+		case "json":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"version":"1.0","type":"video","title":"A video","author_name":"Penelope","author_url":"https://example.com/penelope","provider_name":"Example","thumbnail_url":"https://example.com/thumb.jpg","html":"<iframe src=\"https://example.com/embed/1\"></iframe>","width":480,"height":"270"}`))
+		case "xml":
+			w.Header().Set("Content-Type", "text/xml")
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?><oembed><version>1.0</version><type>photo</type><title>A photo</title><url>https://example.com/photo.jpg</url><width>640</width><height>480</height></oembed>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	html := `<link rel="alternate" type="text/xml+oembed" href="/oembed?format=xml">
+		<link rel="alternate" type="application/json+oembed" href="/oembed?format=json">`
+	data, err := ParseHTML(strings.NewReader(html), "text/html; charset=utf-8", ts.URL+"/videos/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewOEmbedResolver(ts.Client())
+	r.Header.Set("Accept", "application/json")
+	item, err := r.AddItem(context.Background(), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Items) != 1 || data.Items[0] != item {
+		t.Fatalf("Result should have been the added item, but it was %v", data.Items)
+	}
+
+	b, err := json.Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(b)
+	expected := `{"type":["https://schema.org/VideoObject"],"properties":{"author":[{"type":["https://schema.org/Person"],"properties":{"name":["Penelope"],"url":["https://example.com/penelope"]}}],"embedHtml":["\u003ciframe src=\"https://example.com/embed/1\"\u003e\u003c/iframe\u003e"],"height":[270],"name":["A video"],"provider":[{"type":["https://schema.org/Organization"],"properties":{"name":["Example"]}}],"thumbnailUrl":["https://example.com/thumb.jpg"],"width":[480]},"origin":"oembed"}`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+	if accept != "application/json" {
+		t.Errorf("Accept should have been \"application/json\", but it was \"%s\"", accept)
+	}
+
+	o, err := r.Resolve(context.Background(), data.OEmbed[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err = json.Marshal(o.Item())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result = string(b)
+	expected = `{"type":["https://schema.org/ImageObject"],"properties":{"contentUrl":["https://example.com/photo.jpg"],"height":[480],"name":["A photo"],"width":[640]},"origin":"oembed"}`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
+
+func TestOEmbedResolverStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	r := NewOEmbedResolver(ts.Client())
+	_, err := r.Resolve(context.Background(), OEmbedEndpoint{URL: ts.URL + "/oembed", Format: OEmbedJSON})
+	var fetchErr *FetchError
+	if !errors.As(err, &fetchErr) || !errors.Is(err, ErrUnexpectedStatus) || fetchErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Result should have been an unexpected status error, but it was %v", err)
+	}
+
+	if item, err := r.AddItem(context.Background(), &Microdata{}); item != nil || err != nil {
+		t.Errorf("Result should have been no item, but it was %v, %v", item, err)
+	}
+}
@@ -0,0 +1,425 @@
+package microdata
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// openGraphNS is the namespace of the Open Graph protocol.
+const openGraphNS = "http://ogp.me/ns#"
+
+// OpenGraph holds the Open Graph protocol metadata of a document, see https://ogp.me. When a property that takes a
+// single value occurs more than once, the first occurrence is used.
+type OpenGraph struct {
+	Type             string           `json:"type,omitempty"`
+	Title            string           `json:"title,omitempty"`
+	URL              string           `json:"url,omitempty"`
+	Description      string           `json:"description,omitempty"`
+	Determiner       string           `json:"determiner,omitempty"`
+	SiteName         string           `json:"siteName,omitempty"`
+	Locale           string           `json:"locale,omitempty"`
+	LocaleAlternates []string         `json:"localeAlternates,omitempty"`
+	Images           []OpenGraphMedia `json:"images,omitempty"`
+	Videos           []OpenGraphMedia `json:"videos,omitempty"`
+	Audios           []OpenGraphMedia `json:"audios,omitempty"`
+
+	Article *OpenGraphArticle `json:"article,omitempty"`
+	Music   *OpenGraphMusic   `json:"music,omitempty"`
+	Video   *OpenGraphVideo   `json:"video,omitempty"`
+	Product *OpenGraphProduct `json:"product,omitempty"`
+
+	// Properties holds the values of all the og:, article:, book:, profile:, music:, video: and product: properties
+	// in document order, including the ones without a field.
+	Properties map[string][]string `json:"properties,omitempty"`
+}
+
+// OpenGraphMedia is an og:image, og:video or og:audio with its structured properties.
+type OpenGraphMedia struct {
+	URL       string `json:"url"`
+	SecureURL string `json:"secureUrl,omitempty"`
+	Type      string `json:"type,omitempty"`
+	Width     int    `json:"width,omitempty"`
+	Height    int    `json:"height,omitempty"`
+	Alt       string `json:"alt,omitempty"`
+}
+
+// OpenGraphArticle holds the article: properties of the article type.
+type OpenGraphArticle struct {
+	PublishedTime  string   `json:"publishedTime,omitempty"`
+	ModifiedTime   string   `json:"modifiedTime,omitempty"`
+	ExpirationTime string   `json:"expirationTime,omitempty"`
+	Authors        []string `json:"authors,omitempty"`
+	Section        string   `json:"section,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+}
+
+// OpenGraphMusic holds the music: properties of the music types.
+type OpenGraphMusic struct {
+	Duration    int                   `json:"duration,omitempty"`
+	Albums      []OpenGraphMusicTrack `json:"albums,omitempty"`
+	Songs       []OpenGraphMusicTrack `json:"songs,omitempty"`
+	Musicians   []string              `json:"musicians,omitempty"`
+	Creators    []string              `json:"creators,omitempty"`
+	ReleaseDate string                `json:"releaseDate,omitempty"`
+}
+
+// OpenGraphMusicTrack is a music:album or music:song with its disc and track numbers.
+type OpenGraphMusicTrack struct {
+	URL   string `json:"url"`
+	Disc  int    `json:"disc,omitempty"`
+	Track int    `json:"track,omitempty"`
+}
+
+// OpenGraphVideo holds the video: properties of the video types.
+type OpenGraphVideo struct {
+	Actors      []OpenGraphActor `json:"actors,omitempty"`
+	Directors   []string         `json:"directors,omitempty"`
+	Writers     []string         `json:"writers,omitempty"`
+	Duration    int              `json:"duration,omitempty"`
+	ReleaseDate string           `json:"releaseDate,omitempty"`
+	Tags        []string         `json:"tags,omitempty"`
+	Series      string           `json:"series,omitempty"`
+}
+
+// OpenGraphActor is a video:actor with its role.
+type OpenGraphActor struct {
+	URL  string `json:"url"`
+	Role string `json:"role,omitempty"`
+}
+
+// OpenGraphProduct holds the product: properties used for product pages.
+type OpenGraphProduct struct {
+	Prices         []OpenGraphPrice `json:"prices,omitempty"`
+	Availability   string           `json:"availability,omitempty"`
+	Condition      string           `json:"condition,omitempty"`
+	Brand          string           `json:"brand,omitempty"`
+	RetailerItemID string           `json:"retailerItemId,omitempty"`
+	Category       string           `json:"category,omitempty"`
+}
+
+// OpenGraphPrice is a product:price with its amount and currency.
+type OpenGraphPrice struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// openGraphPrefixes are the prefixes of the Open Graph properties.
+var openGraphPrefixes = []string{"og:", "article:", "book:", "profile:", "music:", "video:", "product:"}
+
+// openGraphStructures are the properties that have structured properties, e.g. og:image:width. The value tells
+// whether the property itself occurs as a tag. For the others, like product:price, a structured property that is
+// already set on the current group starts a new one.
+var openGraphStructures = map[string]bool{
+	"og:image":      true,
+	"og:video":      true,
+	"og:audio":      true,
+	"music:album":   true,
+	"music:song":    true,
+	"video:actor":   true,
+	"product:price": false,
+}
+
+// openGraphProperty is a property found in a <meta property> element.
+type openGraphProperty struct {
+	name, content string
+}
+
+// openGraphGroup is a structured property with its structured properties, keyed by their last name segment. The
+// value of the property itself is keyed by "url".
+type openGraphGroup struct {
+	name   string
+	values map[string]string
+	// tagged is true once a :url property was read for the group.
+	tagged bool
+}
+
+// isOpenGraphProperty returns true if the name has one of the Open Graph prefixes.
+func isOpenGraphProperty(name string) bool {
+	for _, prefix := range openGraphPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// metaOpenGraphProperty returns the Open Graph property of a <meta property content> element. The name attribute is
+// accepted too, as many publishers use it instead of property.
+func metaOpenGraphProperty(n *html.Node) (openGraphProperty, bool) {
+	content, ok := getAttr("content", n)
+	if !ok {
+		return openGraphProperty{}, false
+	}
+	name, ok := getAttr("property", n)
+	if !ok || !isOpenGraphProperty(strings.TrimSpace(name)) {
+		name, ok = getAttr("name", n)
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !ok || !isOpenGraphProperty(name) {
+		return openGraphProperty{}, false
+	}
+	return openGraphProperty{name: name, content: strings.TrimSpace(content)}, true
+}
+
+// openGraphElement is either a plain property or a group of structured properties, in document order.
+type openGraphElement struct {
+	property *openGraphProperty
+	group    *openGraphGroup
+}
+
+// groupOpenGraph groups the structured properties with the property they belong to, following the OGP rules:
+// structured properties belong to the preceding property and structured properties without one are ignored, except
+// for a :url one which stands for the property itself.
+func groupOpenGraph(props []openGraphProperty) []openGraphElement {
+	var elements []openGraphElement
+	current := make(map[string]*openGraphGroup)
+	for i := range props {
+		prop := &props[i]
+		if _, ok := openGraphStructures[prop.name]; ok {
+			group := &openGraphGroup{name: prop.name, values: map[string]string{"url": prop.content}}
+			current[prop.name] = group
+			elements = append(elements, openGraphElement{group: group})
+			continue
+		}
+
+		sep := strings.LastIndexByte(prop.name, ':')
+		root, key := prop.name[:sep], prop.name[sep+1:]
+		hasTag, ok := openGraphStructures[root]
+		if !ok {
+			elements = append(elements, openGraphElement{property: prop})
+			continue
+		}
+
+		group := current[root]
+		if group != nil && hasTag && key == "url" {
+			if !group.tagged {
+				// og:image:url repeating og:image.
+				group.tagged = true
+				continue
+			}
+			group = nil
+		} else if group != nil {
+			if _, ok := group.values[key]; ok {
+				if hasTag {
+					// A conflict where the first value wins.
+					continue
+				}
+				group = nil
+			}
+		}
+		if group == nil {
+			if hasTag && key != "url" {
+				continue
+			}
+			group = &openGraphGroup{name: root, values: make(map[string]string), tagged: key == "url"}
+			current[root] = group
+			elements = append(elements, openGraphElement{group: group})
+		}
+		group.values[key] = prop.content
+	}
+	return elements
+}
+
+// readOpenGraph returns the Open Graph metadata of the given properties, or nil if there are none.
+func (p *parser) readOpenGraph(props []openGraphProperty) *OpenGraph {
+	if len(props) == 0 {
+		return nil
+	}
+
+	og := &OpenGraph{Properties: make(map[string][]string)}
+	for _, prop := range props {
+		og.Properties[prop.name] = append(og.Properties[prop.name], prop.content)
+	}
+
+	for _, e := range groupOpenGraph(props) {
+		if e.group != nil {
+			og.addGroup(e.group, p.resolveURL)
+			continue
+		}
+
+		v := e.property.content
+		switch e.property.name {
+		case "og:type":
+			setFirst(&og.Type, v)
+		case "og:title":
+			setFirst(&og.Title, v)
+		case "og:url":
+			setFirst(&og.URL, p.resolveURL(v))
+		case "og:description":
+			setFirst(&og.Description, v)
+		case "og:determiner":
+			setFirst(&og.Determiner, v)
+		case "og:site_name":
+			setFirst(&og.SiteName, v)
+		case "og:locale":
+			setFirst(&og.Locale, v)
+		case "og:locale:alternate":
+			og.LocaleAlternates = append(og.LocaleAlternates, v)
+		case "article:published_time":
+			setFirst(&og.article().PublishedTime, v)
+		case "article:modified_time":
+			setFirst(&og.article().ModifiedTime, v)
+		case "article:expiration_time":
+			setFirst(&og.article().ExpirationTime, v)
+		case "article:author":
+			og.article().Authors = append(og.article().Authors, v)
+		case "article:section":
+			setFirst(&og.article().Section, v)
+		case "article:tag":
+			og.article().Tags = append(og.article().Tags, v)
+		case "music:duration":
+			setFirstInt(&og.music().Duration, v)
+		case "music:musician":
+			og.music().Musicians = append(og.music().Musicians, v)
+		case "music:creator":
+			og.music().Creators = append(og.music().Creators, v)
+		case "music:release_date":
+			setFirst(&og.music().ReleaseDate, v)
+		case "video:director":
+			og.video().Directors = append(og.video().Directors, v)
+		case "video:writer":
+			og.video().Writers = append(og.video().Writers, v)
+		case "video:duration":
+			setFirstInt(&og.video().Duration, v)
+		case "video:release_date":
+			setFirst(&og.video().ReleaseDate, v)
+		case "video:tag":
+			og.video().Tags = append(og.video().Tags, v)
+		case "video:series":
+			setFirst(&og.video().Series, v)
+		case "product:availability":
+			setFirst(&og.product().Availability, v)
+		case "product:condition":
+			setFirst(&og.product().Condition, v)
+		case "product:brand":
+			setFirst(&og.product().Brand, v)
+		case "product:retailer_item_id":
+			setFirst(&og.product().RetailerItemID, v)
+		case "product:category":
+			setFirst(&og.product().Category, v)
+		}
+	}
+	return og
+}
+
+// addGroup adds a structured property to the metadata.
+func (og *OpenGraph) addGroup(g *openGraphGroup, resolve func(string) string) {
+	switch g.name {
+	case "og:image", "og:video", "og:audio":
+		media := OpenGraphMedia{
+			URL:       resolve(g.values["url"]),
+			SecureURL: resolve(g.values["secure_url"]),
+			Type:      g.values["type"],
+			Alt:       g.values["alt"],
+		}
+		setFirstInt(&media.Width, g.values["width"])
+		setFirstInt(&media.Height, g.values["height"])
+		switch g.name {
+		case "og:image":
+			og.Images = append(og.Images, media)
+		case "og:video":
+			og.Videos = append(og.Videos, media)
+		case "og:audio":
+			og.Audios = append(og.Audios, media)
+		}
+	case "music:album", "music:song":
+		track := OpenGraphMusicTrack{URL: resolve(g.values["url"])}
+		setFirstInt(&track.Disc, g.values["disc"])
+		setFirstInt(&track.Track, g.values["track"])
+		if g.name == "music:album" {
+			og.music().Albums = append(og.music().Albums, track)
+		} else {
+			og.music().Songs = append(og.music().Songs, track)
+		}
+	case "video:actor":
+		og.video().Actors = append(og.video().Actors, OpenGraphActor{URL: resolve(g.values["url"]), Role: g.values["role"]})
+	case "product:price":
+		og.product().Prices = append(og.product().Prices, OpenGraphPrice{Amount: g.values["amount"], Currency: g.values["currency"]})
+	}
+}
+
+func (og *OpenGraph) article() *OpenGraphArticle {
+	if og.Article == nil {
+		og.Article = &OpenGraphArticle{}
+	}
+	return og.Article
+}
+
+func (og *OpenGraph) music() *OpenGraphMusic {
+	if og.Music == nil {
+		og.Music = &OpenGraphMusic{}
+	}
+	return og.Music
+}
+
+func (og *OpenGraph) video() *OpenGraphVideo {
+	if og.Video == nil {
+		og.Video = &OpenGraphVideo{}
+	}
+	return og.Video
+}
+
+func (og *OpenGraph) product() *OpenGraphProduct {
+	if og.Product == nil {
+		og.Product = &OpenGraphProduct{}
+	}
+	return og.Product
+}
+
+// openGraphItem returns the Open Graph metadata of the given properties as an item. Its type is the og:type in
+// the Open Graph namespace, "website" by default, and its ID the og:url. Properties are keyed by their full name,
+// e.g. "og:title", and structured properties are nested items keyed by their last name segment, e.g. "width".
+func (p *parser) openGraphItem(og *OpenGraph, props []openGraphProperty) *Item {
+	item := NewItem()
+	ogType := og.Type
+	if ogType == "" {
+		ogType = "website"
+	}
+	item.addType(openGraphNS + ogType)
+	item.ID = og.URL
+
+	for _, e := range groupOpenGraph(props) {
+		if e.property != nil {
+			item.addProperty(e.property.name, e.property.content)
+			continue
+		}
+
+		nested := NewItem()
+		for key, value := range e.group.values {
+			nested.addProperty(key, value)
+		}
+		item.addItem(e.group.name, nested)
+	}
+	return item
+}
+
+// resolveURL returns the given URL resolved against the base URL, or as is if it can't be parsed.
+func (p *parser) resolveURL(s string) string {
+	if s == "" {
+		return ""
+	}
+	u, err := p.baseURL.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return s
+	}
+	return u.String()
+}
+
+// setFirst sets the field to the value unless it is already set.
+func setFirst(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+// setFirstInt sets the field to the integer value unless it is already set or the value is not an integer.
+func setFirstInt(field *int, value string) {
+	if *field != 0 {
+		return
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+		*field = n
+	}
+}
@@ -0,0 +1,122 @@
+package microdata
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const openGraphSnippet = `<html><head>
+	<meta property="og:type" content="video.movie">
+	<meta property="og:title" content="The Rock">
+	<meta property="og:title" content="Ignored duplicate">
+	<meta property="og:url" content="/movies/the-rock/">
+	<meta name="og:description" content="Sean Connery found fame and fortune.">
+	<meta property="og:locale" content="en_US">
+	<meta property="og:locale:alternate" content="fr_FR">
+	<meta property="og:locale:alternate" content="es_ES">
+	<meta property="og:image:width" content="100">
+	<meta property="og:image" content="https://example.com/rock.jpg">
+	<meta property="og:image:url" content="https://example.com/rock.jpg">
+	<meta property="og:image:width" content="400">
+	<meta property="og:image:height" content="300">
+	<meta property="og:image:width" content="500">
+	<meta property="og:image" content="https://example.com/rock2.jpg">
+	<meta property="og:image" content="/rock3.jpg">
+	<meta property="og:image:height" content="1000">
+	<meta property="video:actor" content="/actors/connery">
+	<meta property="video:actor:role" content="John Mason">
+	<meta property="video:director" content="/directors/bay">
+	<meta property="video:duration" content="136">
+	<meta property="product:price:amount" content="9.99">
+	<meta property="product:price:currency" content="USD">
+	<meta property="product:price:amount" content="8.99">
+	<meta property="product:price:currency" content="EUR">
+	<meta name="description" content="Not Open Graph">
+</head><body></body></html>`
+
+func TestParseOpenGraph(t *testing.T) {
+	data, err := ParseHTML(strings.NewReader(openGraphSnippet), "text/html; charset=utf-8", "https://www.imdb.com/title/tt0117500/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Items) != 0 {
+		t.Errorf("Result should have been 0 items, but it was %d", len(data.Items))
+	}
+
+	og := *data.OpenGraph
+	og.Properties = nil
+	b, err := json.Marshal(og)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(b)
+	expected := `{"type":"video.movie","title":"The Rock","url":"https://www.imdb.com/movies/the-rock/","description":"Sean Connery found fame and fortune.","locale":"en_US","localeAlternates":["fr_FR","es_ES"],"images":[{"url":"https://example.com/rock.jpg","width":400,"height":300},{"url":"https://example.com/rock2.jpg"},{"url":"https://www.imdb.com/rock3.jpg","height":1000}],"video":{"actors":[{"url":"https://www.imdb.com/actors/connery","role":"John Mason"}],"directors":["/directors/bay"],"duration":136},"product":{"prices":[{"amount":"9.99","currency":"USD"},{"amount":"8.99","currency":"EUR"}]}}`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+
+	if titles := data.OpenGraph.Properties["og:title"]; len(titles) != 2 {
+		t.Errorf("Result should have been 2 og:title properties, but it was %v", titles)
+	}
+}
+
+func TestParseOpenGraphItem(t *testing.T) {
+	html := `<meta property="og:type" content="article">
+		<meta property="og:title" content="Hello">
+		<meta property="og:url" content="https://example.com/hello">
+		<meta property="og:image" content="https://example.com/a.png">
+		<meta property="og:image:alt" content="A">
+		<meta property="article:tag" content="go">
+		<meta property="article:tag" content="html">`
+
+	data, err := ParseHTML(strings.NewReader(html), "text/html; charset=utf-8", "https://example.com", WithOpenGraphItem())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(data.Items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(b)
+	expected := `[{"type":["http://ogp.me/ns#article"],"properties":{"article:tag":["go","html"],"og:image":[{"type":[],"properties":{"alt":["A"],"url":["https://example.com/a.png"]}}],"og:title":["Hello"],"og:type":["article"],"og:url":["https://example.com/hello"]},"id":"https://example.com/hello"}]`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+
+	if tags := data.OpenGraph.Article.Tags; len(tags) != 2 {
+		t.Errorf("Result should have been 2 article tags, but it was %v", tags)
+	}
+}
+
+func TestParseOpenGraphURLs(t *testing.T) {
+	html := `<meta property="og:image:url" content="https://example.com/a.png">
+		<meta property="og:image:width" content="100">
+		<meta property="og:image:url" content="https://example.com/b.png">
+		<meta property="og:video" content="https://example.com/a.mp4">
+		<meta property="og:video:url" content="https://example.com/a.mp4">
+		<meta property="og:video:url" content="https://example.com/b.mp4">
+		<meta property="og:audio:url" content="https://example.com/a.mp3">
+		<meta property="og:audio:url" content="https://example.com/b.mp3">`
+
+	data := ParseData(html, t)
+	og := *data.OpenGraph
+	og.Properties = nil
+	b, err := json.Marshal(og)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(b)
+	expected := `{"images":[{"url":"https://example.com/a.png","width":100},{"url":"https://example.com/b.png"}],"videos":[{"url":"https://example.com/a.mp4"},{"url":"https://example.com/b.mp4"}],"audios":[{"url":"https://example.com/a.mp3"},{"url":"https://example.com/b.mp3"}]}`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
+
+func TestParseNoOpenGraph(t *testing.T) {
+	data := ParseData(`<meta name="description" content="Plain">`, t)
+	if data.OpenGraph != nil {
+		t.Errorf("Result should have been no Open Graph metadata, but it was %+v", data.OpenGraph)
+	}
+}
@@ -3,6 +3,7 @@ package microdata
 import (
 	"bytes"
 	"log"
+	"mime"
 	"net/url"
 	"strings"
 
@@ -16,16 +17,46 @@ type parser struct {
 	data            *Microdata
 	baseURL         *url.URL
 	identifiedNodes map[string]*html.Node
+	opts            parseOptions
 }
 
 // parse returns the microdata from the parser's node tree.
 func (p *parser) parse() (*Microdata, error) {
+	p.baseURL = documentBaseURL(p.tree, p.baseURL)
+	p.data.BaseURL = p.baseURL.String()
+	p.data.metadataJSON = p.opts.metadataJSON
+
 	var toplevelNodes []*html.Node
 	var jsonNodes []*html.Node
+	var scriptNodes []*html.Node
+	var fragmentNodes []*html.Node
+	var openGraphProps []openGraphProperty
+	var twitterProps []twitterProperty
+	metadata := &DocumentMetadata{}
 
 	walkNodes(p.tree, func(n *html.Node) {
-		if n.DataAtom == atom.Script && checkAttr("type", "application/ld+json", n) {
+		if isJSONLDScript(n) {
 			jsonNodes = append(jsonNodes, n)
+		} else if n.DataAtom == atom.Script && p.opts.embeddedJSON {
+			scriptNodes = append(scriptNodes, n)
+		}
+
+		if n.DataAtom == atom.Meta {
+			if prop, ok := metaOpenGraphProperty(n); ok {
+				openGraphProps = append(openGraphProps, prop)
+			}
+			if prop, ok := metaTwitterProperty(n); ok {
+				twitterProps = append(twitterProps, prop)
+			}
+		}
+
+		p.readDocumentMetadata(n, metadata)
+		if endpoint, ok := p.oembedEndpoint(n); ok {
+			p.data.OEmbed = append(p.data.OEmbed, endpoint)
+		}
+
+		if p.opts.fragments && (n.DataAtom == atom.Noscript || n.DataAtom == atom.Iframe) {
+			fragmentNodes = append(fragmentNodes, n)
 		}
 
 		if _, ok := getAttr("itemscope", n); ok {
@@ -39,27 +70,71 @@ func (p *parser) parse() (*Microdata, error) {
 		}
 	})
 
+	if !metadata.isEmpty() {
+		p.data.Metadata = metadata
+	}
+
 	for _, node := range toplevelNodes {
 		item := NewItem()
+		item.Origin = p.nodeOrigin(node)
 		p.data.addItem(item)
 		p.readAttr(item, node)
 		p.readItem(item, node, true)
 	}
 
 	for _, node := range jsonNodes {
-		if node.FirstChild != nil {
-			data := []byte(node.FirstChild.Data)
+		if text := unwrapScript(scriptText(node)); text != "" {
+			data := []byte(text)
 
 			var jsonMap interface{}
 			err := fixjson.Unmarshal(data, &jsonMap)
 			if err == nil {
-				p.readJsonItem(nil, jsonMap)
+				first := len(p.data.Items)
+				if !p.opts.jsonldExpansion || !p.readExpandedJSONLD(jsonMap) {
+					p.readJsonItem(nil, jsonMap)
+				}
+				p.data.scripts++
+				for _, item := range p.data.Items[first:] {
+					item.script = p.data.scripts
+				}
+				if origin := p.nodeOrigin(node); origin != "" {
+					for _, item := range p.data.Items[first:] {
+						item.Origin = origin
+					}
+				}
 			} else {
 				log.Println("Error parsing json:", err)
 			}
 		}
 	}
 
+	for _, node := range scriptNodes {
+		p.readEmbeddedJSON(node)
+	}
+
+	p.readRDFa(p.tree, rdfaLiteContext{})
+
+	for _, node := range fragmentNodes {
+		p.readFragment(node)
+	}
+
+	p.data.OpenGraph = p.readOpenGraph(openGraphProps)
+	if p.data.OpenGraph != nil && p.opts.openGraphItem {
+		p.data.addItem(p.openGraphItem(p.data.OpenGraph, openGraphProps))
+	}
+	p.data.Twitter = p.readTwitterCard(twitterProps, p.data.OpenGraph)
+
+	p.data.Microformats = p.readMicroformats()
+	if p.data.Microformats != nil && p.opts.microformatItems {
+		for _, mf := range p.data.Microformats.Items {
+			p.data.addItem(mf.Item())
+		}
+	}
+
+	if p.opts.flatten {
+		p.data.Flatten()
+	}
+
 	return p.data, nil
 }
 
@@ -124,6 +199,58 @@ func (p *parser) readJsonProp(item *Item, key string, value interface{}) {
 	}
 }
 
+// isJSONLDScript returns true if the node is a <script type="application/ld+json"> element. The type is compared
+// as a MIME type, ignoring case, surrounding whitespace and parameters like charset.
+func isJSONLDScript(node *html.Node) bool {
+	if node.Type != html.ElementNode || node.DataAtom != atom.Script {
+		return false
+	}
+	typ, ok := getAttr("type", node)
+	if !ok {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(typ))
+	return err == nil && mediaType == "application/ld+json"
+}
+
+// scriptWrappers are the markers that legacy pages wrap script content in, as pairs of prefix and suffix.
+var scriptWrappers = [][2]string{
+	{"<!--", "-->"},
+	{"//<![CDATA[", "//]]>"},
+	{"/*<![CDATA[*/", "/*]]>*/"},
+	{"<![CDATA[", "]]>"},
+}
+
+// unwrapScript returns the trimmed script content without the HTML comment and CDATA markers wrapped around it.
+func unwrapScript(s string) string {
+	s = strings.TrimSpace(s)
+	for changed := true; changed; {
+		changed = false
+		for _, w := range scriptWrappers {
+			if strings.HasPrefix(s, w[0]) {
+				s = strings.TrimSpace(strings.TrimPrefix(s, w[0]))
+				changed = true
+			}
+			if strings.HasSuffix(s, w[1]) {
+				s = strings.TrimSpace(strings.TrimSuffix(s, w[1]))
+				changed = true
+			}
+		}
+	}
+	return s
+}
+
+// scriptText returns the text of a script element, concatenating all its text nodes.
+func scriptText(node *html.Node) string {
+	var b strings.Builder
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+		}
+	}
+	return b.String()
+}
+
 // readItem traverses the given node tree, applying relevant attributes to the given item.
 func (p *parser) readItem(item *Item, node *html.Node, isToplevel bool) {
 	itemprops, hasProp := getAttr("itemprop", node)
@@ -151,6 +278,9 @@ func (p *parser) readItem(item *Item, node *html.Node, isToplevel bool) {
 					} else {
 						item.addProperty(propName, s)
 					}
+					if urlPropertyElements[node.DataAtom] {
+						item.addURL(propName, s)
+					}
 				}
 			}
 		}
@@ -190,6 +320,21 @@ func (p *parser) readAttr(item *Item, node *html.Node) {
 	}
 }
 
+// urlPropertyElements are the elements whose microdata property values are absolute URLs.
+var urlPropertyElements = map[atom.Atom]bool{
+	atom.A:      true,
+	atom.Area:   true,
+	atom.Audio:  true,
+	atom.Embed:  true,
+	atom.Iframe: true,
+	atom.Img:    true,
+	atom.Link:   true,
+	atom.Object: true,
+	atom.Source: true,
+	atom.Track:  true,
+	atom.Video:  true,
+}
+
 // getValue returns the value and innerHTML of the property in the given node.
 // innerHTML is only set for text-based properties (not attribute-based like href, src, etc.)
 func (p *parser) getValue(node *html.Node) (propValue string, innerHTML string) {
@@ -221,6 +366,12 @@ func (p *parser) getValue(node *html.Node) (propValue string, innerHTML string)
 				propValue = u.String()
 			}
 		}
+	case atom.Object:
+		if value, ok := getAttr("data", node); ok {
+			if u, err := p.baseURL.Parse(value); err == nil {
+				propValue = u.String()
+			}
+		}
 	case atom.Data, atom.Meter:
 		if value, ok := getAttr("value", node); ok {
 			propValue = value
@@ -256,12 +407,34 @@ func (p *parser) getValue(node *html.Node) (propValue string, innerHTML string)
 	return
 }
 
+// documentBaseURL returns the document base URL: the href of the first <base> element that has one, resolved
+// against the document URL. The document URL is returned when there is no such element.
+func documentBaseURL(root *html.Node, docURL *url.URL) *url.URL {
+	base := findNode(root, func(n *html.Node) bool {
+		if n.Type != html.ElementNode || n.DataAtom != atom.Base {
+			return false
+		}
+		_, ok := getAttr("href", n)
+		return ok
+	})
+	if base == nil {
+		return docURL
+	}
+
+	href, _ := getAttr("href", base)
+	if u, err := docURL.Parse(strings.TrimSpace(href)); err == nil {
+		return u
+	}
+	return docURL
+}
+
 // newParser returns a parser that converts the contents of the given node tree to microdata.
-func newParser(root *html.Node, baseURL *url.URL) (*parser, error) {
+func newParser(root *html.Node, baseURL *url.URL, opts parseOptions) (*parser, error) {
 	return &parser{
 		tree:            root,
 		data:            &Microdata{},
 		baseURL:         baseURL,
 		identifiedNodes: make(map[string]*html.Node),
+		opts:            opts,
 	}, nil
 }
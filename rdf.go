@@ -0,0 +1,308 @@
+package microdata
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Well-known IRIs used when producing RDF.
+const (
+	rdfNS          = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	rdfType        = rdfNS + "type"
+	rdfFirst       = rdfNS + "first"
+	rdfRest        = rdfNS + "rest"
+	rdfNil         = rdfNS + "nil"
+	rdfXMLLiteral  = rdfNS + "XMLLiteral"
+	rdfHTML        = rdfNS + "HTML"
+	rdfLangString  = rdfNS + "langString"
+	rdfJSON        = rdfNS + "JSON"
+	xsdNS          = "http://www.w3.org/2001/XMLSchema#"
+	xsdString      = xsdNS + "string"
+	xsdBoolean     = xsdNS + "boolean"
+	xsdInteger     = xsdNS + "integer"
+	xsdDouble      = xsdNS + "double"
+	rdfaNS         = "http://www.w3.org/ns/rdfa#"
+	rdfaUsesVocab  = rdfaNS + "usesVocabulary"
+	xhvNS          = "http://www.w3.org/1999/xhtml/vocab#"
+	powderDescribe = "http://www.w3.org/2007/05/powder-s#describedby"
+)
+
+// TermKind is the kind of an RDF term.
+type TermKind int
+
+const (
+	// IRI is a term identifying a resource with an IRI.
+	IRI TermKind = iota + 1
+	// BlankNode is a term identifying a resource local to the graph.
+	BlankNode
+	// Literal is a term holding a value.
+	Literal
+)
+
+// Term is an RDF term: an IRI, a blank node or a literal.
+type Term struct {
+	Kind TermKind `json:"kind"`
+	// Value is the IRI, the blank node label (without "_:") or the lexical form of the literal.
+	Value string `json:"value"`
+	// Datatype is the datatype IRI of a typed literal.
+	Datatype string `json:"datatype,omitempty"`
+	// Language is the language tag of a literal.
+	Language string `json:"language,omitempty"`
+}
+
+// NewIRI returns an IRI term.
+func NewIRI(iri string) Term {
+	return Term{Kind: IRI, Value: iri}
+}
+
+// NewBlankNode returns a blank node term with the given label.
+func NewBlankNode(label string) Term {
+	return Term{Kind: BlankNode, Value: label}
+}
+
+// NewLiteral returns a literal term. The datatype and language are optional.
+func NewLiteral(value, datatype, language string) Term {
+	return Term{Kind: Literal, Value: value, Datatype: datatype, Language: language}
+}
+
+// String returns the term in N-Triples syntax.
+func (t Term) String() string {
+	switch t.Kind {
+	case IRI:
+		return "<" + escapeIRI(t.Value) + ">"
+	case BlankNode:
+		return "_:" + t.Value
+	case Literal:
+		s := `"` + escapeLiteral(t.Value) + `"`
+		switch {
+		case t.Language != "":
+			return s + "@" + t.Language
+		case t.Datatype != "" && t.Datatype != xsdString:
+			return s + "^^<" + escapeIRI(t.Datatype) + ">"
+		}
+		return s
+	}
+	return ""
+}
+
+// escapeLiteral escapes the lexical form of a literal as in canonical N-Triples.
+func escapeLiteral(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				b.WriteString(`\u`)
+				b.WriteString(strings.ToUpper(leftPad(strconv.FormatInt(int64(r), 16), 4)))
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
+
+// escapeIRI escapes the characters that are not allowed in an N-Triples IRI reference.
+func escapeIRI(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r <= 0x20 || strings.ContainsRune(`<>"{}|^`+"`\\", r) {
+			b.WriteString(`\u`)
+			b.WriteString(strings.ToUpper(leftPad(strconv.FormatInt(int64(r), 16), 4)))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// leftPad pads s with zeros to the given length.
+func leftPad(s string, n int) string {
+	for len(s) < n {
+		s = "0" + s
+	}
+	return s
+}
+
+// Triple is an RDF statement.
+type Triple struct {
+	Subject   Term `json:"subject"`
+	Predicate Term `json:"predicate"`
+	Object    Term `json:"object"`
+}
+
+// String returns the triple as an N-Triples line without the line break.
+func (t Triple) String() string {
+	return t.Subject.String() + " " + t.Predicate.String() + " " + t.Object.String() + " ."
+}
+
+// Graph is a set of RDF triples.
+type Graph struct {
+	Triples []Triple `json:"triples"`
+}
+
+// add appends a triple to the graph.
+func (g *Graph) add(subject, predicate, object Term) {
+	g.Triples = append(g.Triples, Triple{Subject: subject, Predicate: predicate, Object: object})
+}
+
+// String returns the graph in N-Triples syntax.
+func (g *Graph) String() string {
+	var b strings.Builder
+	for _, t := range g.Triples {
+		b.WriteString(t.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Items converts the graph to items. Every IRI subject, and every blank node subject that is not the object of a
+// triple, becomes a top-level item with its rdf:type values as types and its IRI as ID. Blank node objects are
+// nested as items, RDF lists are expanded to multiple values and other objects become string values. Predicates in
+// the namespace of one of the item's types are keyed by their local name, like microdata properties, other
+// predicates by their full IRI.
+func (g *Graph) Items() []*Item {
+	bySubject := make(map[Term][]Triple)
+	var subjects []Term
+	referenced := make(map[Term]bool)
+	for _, t := range g.Triples {
+		if t.Predicate.Value == rdfaUsesVocab {
+			continue
+		}
+		if _, ok := bySubject[t.Subject]; !ok {
+			subjects = append(subjects, t.Subject)
+		}
+		bySubject[t.Subject] = append(bySubject[t.Subject], t)
+		if t.Object.Kind == BlankNode {
+			referenced[t.Object] = true
+		}
+	}
+
+	c := &graphConverter{bySubject: bySubject, path: make(map[Term]bool)}
+	var items []*Item
+	for _, s := range subjects {
+		if s.Kind == BlankNode && referenced[s] {
+			continue
+		}
+		items = append(items, c.item(s))
+	}
+	return items
+}
+
+// graphConverter converts the triples of a graph to items.
+type graphConverter struct {
+	bySubject map[Term][]Triple
+	// path holds the subjects being converted, to break cycles.
+	path map[Term]bool
+}
+
+// item returns the item describing the given subject.
+func (c *graphConverter) item(subject Term) *Item {
+	c.path[subject] = true
+	defer delete(c.path, subject)
+
+	item := NewItem()
+	if subject.Kind == IRI {
+		item.ID = subject.Value
+	}
+
+	triples := c.bySubject[subject]
+	for _, t := range triples {
+		if t.Predicate.Value == rdfType && t.Object.Kind == IRI {
+			item.addType(t.Object.Value)
+		}
+	}
+
+	for _, t := range triples {
+		if t.Predicate.Value == rdfType && t.Object.Kind == IRI {
+			continue
+		}
+		key := propertyName(t.Predicate.Value, item.Types)
+		for _, v := range c.values(t.Object) {
+			item.Properties[key] = append(item.Properties[key], v)
+		}
+	}
+	return item
+}
+
+// values returns the property values of the given object: the members of an RDF list, a nested item for a blank
+// node or the string value of other terms.
+func (c *graphConverter) values(object Term) []interface{} {
+	if object.Kind == IRI && object.Value == rdfNil {
+		return nil
+	}
+	if object.Kind != BlankNode || c.path[object] {
+		return []interface{}{termValue(object)}
+	}
+
+	if first, rest, ok := c.listCell(object); ok {
+		c.path[object] = true
+		defer delete(c.path, object)
+		return append(c.values(first), c.values(rest)...)
+	}
+	return []interface{}{c.item(object)}
+}
+
+// listCell returns the rdf:first and rdf:rest values of the given node if it is an RDF list cell.
+func (c *graphConverter) listCell(node Term) (first, rest Term, ok bool) {
+	triples := c.bySubject[node]
+	if len(triples) != 2 {
+		return
+	}
+	var hasFirst, hasRest bool
+	for _, t := range triples {
+		switch t.Predicate.Value {
+		case rdfFirst:
+			first, hasFirst = t.Object, true
+		case rdfRest:
+			rest, hasRest = t.Object, true
+		}
+	}
+	return first, rest, hasFirst && hasRest
+}
+
+// termValue returns the string value of a term: the IRI, "_:label" for blank nodes or the literal value.
+func termValue(t Term) string {
+	if t.Kind == BlankNode {
+		return "_:" + t.Value
+	}
+	return t.Value
+}
+
+// propertyName returns the local name of the predicate when it shares the namespace of one of the given types,
+// and the predicate itself otherwise.
+func propertyName(predicate string, types []string) string {
+	ns, local := splitIRI(predicate)
+	if local == "" {
+		return predicate
+	}
+	for _, t := range types {
+		if typeNS, _ := splitIRI(t); typeNS == ns {
+			return local
+		}
+	}
+	return predicate
+}
+
+// splitIRI splits an IRI into its namespace, up to the last '#' or '/', and its local name.
+func splitIRI(iri string) (ns, local string) {
+	i := strings.LastIndexAny(iri, "#/")
+	if i < 0 {
+		return "", iri
+	}
+	return iri[:i+1], iri[i+1:]
+}
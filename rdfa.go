@@ -0,0 +1,815 @@
+package microdata
+
+import (
+	"bytes"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// rdfaInitialPrefixes are the prefixes of the RDFa 1.1 initial context, see https://www.w3.org/2011/rdfa-context/rdfa-1.1.
+var rdfaInitialPrefixes = map[string]string{
+	"as":      "https://www.w3.org/ns/activitystreams#",
+	"cc":      "http://creativecommons.org/ns#",
+	"csvw":    "http://www.w3.org/ns/csvw#",
+	"ctag":    "http://commontag.org/ns#",
+	"dc":      "http://purl.org/dc/terms/",
+	"dc11":    "http://purl.org/dc/elements/1.1/",
+	"dcat":    "http://www.w3.org/ns/dcat#",
+	"dcterms": "http://purl.org/dc/terms/",
+	"dqv":     "http://www.w3.org/ns/dqv#",
+	"duv":     "https://www.w3.org/ns/duv#",
+	"foaf":    "http://xmlns.com/foaf/0.1/",
+	"gr":      "http://purl.org/goodrelations/v1#",
+	"grddl":   "http://www.w3.org/2003/g/data-view#",
+	"ical":    "http://www.w3.org/2002/12/cal/icaltzd#",
+	"jsonld":  "http://www.w3.org/ns/json-ld#",
+	"ldp":     "http://www.w3.org/ns/ldp#",
+	"ma":      "http://www.w3.org/ns/ma-ont#",
+	"oa":      "http://www.w3.org/ns/oa#",
+	"odrl":    "http://www.w3.org/ns/odrl/2/",
+	"og":      "http://ogp.me/ns#",
+	"org":     "http://www.w3.org/ns/org#",
+	"owl":     "http://www.w3.org/2002/07/owl#",
+	"prov":    "http://www.w3.org/ns/prov#",
+	"qb":      "http://purl.org/linked-data/cube#",
+	"rdf":     "http://www.w3.org/1999/02/22-rdf-syntax-ns#",
+	"rdfa":    "http://www.w3.org/ns/rdfa#",
+	"rdfs":    "http://www.w3.org/2000/01/rdf-schema#",
+	"rev":     "http://purl.org/stuff/rev#",
+	"rif":     "http://www.w3.org/2007/rif#",
+	"rr":      "http://www.w3.org/ns/r2rml#",
+	"schema":  "http://schema.org/",
+	"sd":      "http://www.w3.org/ns/sparql-service-description#",
+	"sioc":    "http://rdfs.org/sioc/ns#",
+	"skos":    "http://www.w3.org/2004/02/skos/core#",
+	"skosxl":  "http://www.w3.org/2008/05/skos-xl#",
+	"sosa":    "http://www.w3.org/ns/sosa/",
+	"ssn":     "http://www.w3.org/ns/ssn/",
+	"time":    "http://www.w3.org/2006/time#",
+	"v":       "http://rdf.data-vocabulary.org/#",
+	"vcard":   "http://www.w3.org/2006/vcard/ns#",
+	"void":    "http://rdfs.org/ns/void#",
+	"wdr":     "http://www.w3.org/2007/05/powder#",
+	"wdrs":    "http://www.w3.org/2007/05/powder-s#",
+	"xhv":     "http://www.w3.org/1999/xhtml/vocab#",
+	"xml":     "http://www.w3.org/XML/1998/namespace",
+	"xsd":     "http://www.w3.org/2001/XMLSchema#",
+}
+
+// rdfaLiteContext holds the evaluation context of the RDFa Lite pass for an element.
+type rdfaLiteContext struct {
+	vocab    string
+	prefixes map[string]string
+	// item is the item the properties of the element belong to, nil outside of a typeof element.
+	item *Item
+}
+
+// readRDFa traverses the given node tree and adds the items described with the RDFa Lite 1.1 attributes vocab,
+// typeof, property, resource and prefix.
+func (p *parser) readRDFa(node *html.Node, ctx rdfaLiteContext) {
+	if node.Type == html.ElementNode {
+		if vocab, ok := getAttr("vocab", node); ok {
+			ctx.vocab = strings.TrimSpace(vocab)
+		}
+		if prefix, ok := getAttr("prefix", node); ok {
+			ctx.prefixes = parseRDFaPrefixes(prefix, ctx.prefixes)
+		}
+
+		props, hasProp := getAttr("property", node)
+		types, hasType := getAttr("typeof", node)
+		switch {
+		case hasType:
+			item := NewItem()
+			for _, t := range strings.Fields(types) {
+				if iri := ctx.expand(t); iri != "" {
+					item.addType(iri)
+				}
+			}
+			if resource, ok := getAttr("resource", node); ok {
+				if u, err := p.baseURL.Parse(resource); err == nil {
+					item.ID = u.String()
+				}
+			}
+
+			if hasProp && ctx.item != nil {
+				for _, prop := range strings.Fields(props) {
+					if key := ctx.propertyKey(prop); key != "" {
+						ctx.item.addItem(key, item)
+					}
+				}
+			} else {
+				item.Origin = p.nodeOrigin(node)
+				p.data.addItem(item)
+			}
+			ctx.item = item
+		case hasProp && ctx.item != nil:
+			var value, innerHTML string
+			if resource, ok := getAttr("resource", node); ok {
+				if u, err := p.baseURL.Parse(resource); err == nil {
+					value = u.String()
+				}
+			} else {
+				value, innerHTML = p.getValue(node)
+			}
+
+			for _, prop := range strings.Fields(props) {
+				key := ctx.propertyKey(prop)
+				if key == "" {
+					continue
+				}
+				if innerHTML != "" {
+					ctx.item.addPropertyWithHTML(key, value, innerHTML)
+				} else {
+					ctx.item.addProperty(key, value)
+				}
+			}
+		}
+	}
+
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		p.readRDFa(c, ctx)
+	}
+}
+
+// expand returns the IRI of the given term, compact IRI (CURIE) or absolute IRI. Terms are relative to the vocab
+// and are returned as is when there is none. Blank node CURIEs and unknown values yield "".
+func (ctx *rdfaLiteContext) expand(value string) string {
+	if prefix, reference, ok := strings.Cut(value, ":"); ok {
+		if prefix == "_" {
+			return ""
+		}
+		if ns, ok := ctx.namespace(prefix); ok {
+			return ns + reference
+		}
+		// An absolute IRI.
+		return value
+	}
+	return ctx.vocab + value
+}
+
+// propertyKey returns the key of a property in the Item properties. Like in microdata, properties in the vocabulary
+// are keyed by their name, other properties by their full IRI.
+func (ctx *rdfaLiteContext) propertyKey(value string) string {
+	iri := ctx.expand(value)
+	if ctx.vocab != "" && strings.HasPrefix(iri, ctx.vocab) && len(iri) > len(ctx.vocab) {
+		return iri[len(ctx.vocab):]
+	}
+	return iri
+}
+
+// namespace returns the IRI mapped to the given prefix by a prefix attribute or the initial context.
+func (ctx *rdfaLiteContext) namespace(prefix string) (string, bool) {
+	prefix = strings.ToLower(prefix)
+	if ns, ok := ctx.prefixes[prefix]; ok {
+		return ns, true
+	}
+	ns, ok := rdfaInitialPrefixes[prefix]
+	return ns, ok
+}
+
+// parseRDFaPrefixes returns a copy of the given prefix mappings extended with the ones of a prefix attribute value,
+// e.g. "ov: http://open.vocab.org/terms/ dc: http://purl.org/dc/terms/".
+func parseRDFaPrefixes(value string, prefixes map[string]string) map[string]string {
+	result := make(map[string]string, len(prefixes))
+	for k, v := range prefixes {
+		result[k] = v
+	}
+
+	fields := strings.Fields(value)
+	for i := 0; i+1 < len(fields); i++ {
+		name, ok := strings.CutSuffix(fields[i], ":")
+		if !ok || name == "" || name == "_" {
+			continue
+		}
+		result[strings.ToLower(name)] = fields[i+1]
+		i++
+	}
+	return result
+}
+
+// rdfaInitialTerms are the terms of the RDFa 1.1 initial context.
+var rdfaInitialTerms = map[string]string{
+	"describedby": powderDescribe,
+	"license":     xhvNS + "license",
+	"role":        xhvNS + "role",
+}
+
+// Directions of incomplete triples.
+const (
+	rdfaNone = iota
+	rdfaForward
+	rdfaReverse
+)
+
+// rdfaIncomplete is a triple waiting for its subject or object.
+type rdfaIncomplete struct {
+	predicate string
+	direction int
+	list      *rdfaList
+}
+
+// rdfaList collects the members of an RDF list created with @inlist.
+type rdfaList struct {
+	members []Term
+}
+
+// rdfaLists maps predicates to lists, keeping the order in which the lists were created.
+type rdfaLists struct {
+	predicates []string
+	lists      map[string]*rdfaList
+}
+
+func newRDFaLists() *rdfaLists {
+	return &rdfaLists{lists: make(map[string]*rdfaList)}
+}
+
+// get returns the list for the predicate, creating it if needed.
+func (l *rdfaLists) get(predicate string) *rdfaList {
+	list, ok := l.lists[predicate]
+	if !ok {
+		list = &rdfaList{}
+		l.lists[predicate] = list
+		l.predicates = append(l.predicates, predicate)
+	}
+	return list
+}
+
+// rdfaContext is the evaluation context of the RDFa Core processing model.
+type rdfaContext struct {
+	parentSubject *Term
+	parentObject  *Term
+	prefixes      map[string]string
+	incomplete    []rdfaIncomplete
+	lists         *rdfaLists
+	language      string
+	vocab         string
+}
+
+// rdfaProcessor processes a node tree with the RDFa Core 1.1 processing model.
+type rdfaProcessor struct {
+	base   Term
+	graph  *Graph
+	bnodes map[string]Term
+	next   int
+}
+
+// ParseRDFa processes the RDFa 1.1 attributes of the given node tree following the RDFa Core 1.1 processing model
+// and the HTML+RDFa 1.1 rules, and returns the resulting graph. It supports about, rel and rev chaining with
+// incomplete triples, resource, href and src subjects and objects, typeof, property, content, datatype, inlist,
+// vocab, prefix and the prefixes and terms of the initial context. The given url, or the document's <base href>,
+// is used to resolve relative IRIs. Use Graph.Items to convert the result to items.
+func ParseRDFa(root *html.Node, urlStr string) (*Graph, error) {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, err
+	}
+
+	base := NewIRI(documentBaseURL(root, u).String())
+	p := &rdfaProcessor{
+		base:   base,
+		graph:  &Graph{},
+		bnodes: make(map[string]Term),
+	}
+	p.process(root, &rdfaContext{
+		parentSubject: &base,
+		lists:         newRDFaLists(),
+	})
+	return p.graph, nil
+}
+
+// process applies the processing rules to the element and its descendants.
+func (p *rdfaProcessor) process(node *html.Node, ctx *rdfaContext) {
+	if node.Type != html.ElementNode {
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			p.process(c, ctx)
+		}
+		return
+	}
+
+	// Step 1: local values.
+	skip := false
+	var newSubject, currentObject, typedResource *Term
+	prefixes := ctx.prefixes
+	var incomplete []rdfaIncomplete
+	lists := ctx.lists
+	language := ctx.language
+	vocab := ctx.vocab
+
+	// Step 2: the default vocabulary.
+	if v, ok := getAttr("vocab", node); ok {
+		vocab = ""
+		if v = strings.TrimSpace(v); v != "" {
+			vocab = p.resolve(v)
+			p.graph.add(p.base, NewIRI(rdfaUsesVocab), NewIRI(vocab))
+		}
+	}
+
+	// Step 3: prefix mappings from xmlns:* and prefix.
+	for _, a := range node.Attr {
+		if name, ok := strings.CutPrefix(a.Key, "xmlns:"); ok {
+			prefixes = parseRDFaPrefixes(name+": "+a.Val, prefixes)
+		}
+	}
+	if v, ok := getAttr("prefix", node); ok {
+		prefixes = parseRDFaPrefixes(v, prefixes)
+	}
+
+	// Step 4: the language.
+	if v, ok := getAttr("xml:lang", node); ok {
+		language = strings.TrimSpace(v)
+	} else if v, ok := getAttr("lang", node); ok {
+		language = strings.TrimSpace(v)
+	}
+
+	local := &rdfaContext{prefixes: prefixes, vocab: vocab}
+
+	about := p.attrResource(node, "about", local)
+	resource := p.attrResource(node, "resource", local)
+	if resource == nil {
+		resource = p.attrIRI(node, "href")
+	}
+	if resource == nil {
+		resource = p.attrIRI(node, "src")
+	}
+	if about == nil && resource == nil && (node.DataAtom == atom.Head || node.DataAtom == atom.Body) {
+		// HTML+RDFa: head and body elements without a resource act as if they had about="".
+		about = p.baseTerm()
+	}
+
+	_, hasProperty := getAttr("property", node)
+	_, hasTypeof := getAttr("typeof", node)
+	content, hasContent := getAttr("content", node)
+	datatypeAttr, hasDatatype := getAttr("datatype", node)
+	_, hasInlist := getAttr("inlist", node)
+
+	rels := p.attrIRIs(node, "rel", local, hasProperty)
+	revs := p.attrIRIs(node, "rev", local, hasProperty)
+	hasRel, hasRev := rels != nil, revs != nil
+
+	isRoot := node.Parent == nil || node.Parent.Type == html.DocumentNode
+
+	if !hasRel && !hasRev {
+		// Step 5: no rel and rev.
+		if hasProperty && !hasContent && !hasDatatype {
+			switch {
+			case about != nil:
+				newSubject = about
+			case isRoot:
+				newSubject = p.baseTerm()
+			case ctx.parentObject != nil:
+				newSubject = ctx.parentObject
+			}
+
+			if hasTypeof {
+				switch {
+				case about != nil:
+					typedResource = about
+				case isRoot:
+					typedResource = p.baseTerm()
+				default:
+					typedResource = resource
+					if typedResource == nil {
+						typedResource = p.newBlankNode()
+					}
+					currentObject = typedResource
+				}
+			}
+		} else {
+			switch {
+			case about != nil:
+				newSubject = about
+			case resource != nil:
+				newSubject = resource
+			case isRoot:
+				newSubject = p.baseTerm()
+			case hasTypeof:
+				newSubject = p.newBlankNode()
+			case ctx.parentObject != nil:
+				newSubject = ctx.parentObject
+				if !hasProperty {
+					skip = true
+				}
+			}
+
+			if hasTypeof {
+				typedResource = newSubject
+			}
+		}
+	} else {
+		// Step 6: rel or rev.
+		switch {
+		case about != nil:
+			newSubject = about
+			if hasTypeof {
+				typedResource = about
+			}
+		case isRoot:
+			newSubject = p.baseTerm()
+		case ctx.parentObject != nil:
+			newSubject = ctx.parentObject
+		}
+
+		switch {
+		case resource != nil:
+			currentObject = resource
+		case hasTypeof && about == nil:
+			currentObject = p.newBlankNode()
+		}
+		if hasTypeof && about == nil {
+			typedResource = currentObject
+		}
+	}
+
+	// Step 7: types.
+	if typedResource != nil {
+		for _, t := range p.attrIRIs(node, "typeof", local, false) {
+			p.graph.add(*typedResource, NewIRI(rdfType), t)
+		}
+	}
+
+	// Step 8: a new subject starts new lists.
+	listsCreated := false
+	if newSubject != nil && !sameTerm(newSubject, ctx.parentObject) {
+		lists = newRDFaLists()
+		listsCreated = true
+	}
+
+	if currentObject != nil {
+		// Step 9: triples with the current object resource.
+		if newSubject != nil {
+			for _, rel := range rels {
+				if hasInlist {
+					list := lists.get(rel.Value)
+					list.members = append(list.members, *currentObject)
+				} else {
+					p.graph.add(*newSubject, rel, *currentObject)
+				}
+			}
+			for _, rev := range revs {
+				p.graph.add(*currentObject, rev, *newSubject)
+			}
+		}
+	} else if hasRel || hasRev {
+		// Step 10: incomplete triples waiting for a subject in the descendants.
+		currentObject = p.newBlankNode()
+		for _, rel := range rels {
+			if hasInlist {
+				incomplete = append(incomplete, rdfaIncomplete{direction: rdfaNone, list: lists.get(rel.Value)})
+			} else {
+				incomplete = append(incomplete, rdfaIncomplete{predicate: rel.Value, direction: rdfaForward})
+			}
+		}
+		for _, rev := range revs {
+			incomplete = append(incomplete, rdfaIncomplete{predicate: rev.Value, direction: rdfaReverse})
+		}
+	}
+
+	// Step 11: the property value.
+	if hasProperty && newSubject != nil {
+		var datatype *Term
+		if hasDatatype {
+			if dt := p.termOrIRI(strings.TrimSpace(datatypeAttr), local, false); dt != nil && dt.Kind == IRI {
+				datatype = dt
+			}
+		}
+
+		var value Term
+		switch {
+		case datatype != nil && datatype.Value == rdfXMLLiteral:
+			value = NewLiteral(innerHTML(node), rdfXMLLiteral, "")
+		case datatype != nil && datatype.Value == rdfHTML:
+			value = NewLiteral(innerHTML(node), rdfHTML, "")
+		case datatype != nil:
+			v := content
+			if !hasContent {
+				v = textContent(node)
+			}
+			value = NewLiteral(v, datatype.Value, "")
+		case hasDatatype:
+			// An empty or unknown datatype yields a plain literal.
+			v := content
+			if !hasContent {
+				v = textContent(node)
+			}
+			value = NewLiteral(v, "", language)
+		case hasContent:
+			value = NewLiteral(content, "", language)
+		case !hasRel && !hasRev && resource != nil:
+			value = *resource
+		case hasTypeof && about == nil && typedResource != nil:
+			value = *typedResource
+		case node.DataAtom == atom.Time:
+			v, ok := getAttr("datetime", node)
+			if !ok {
+				v = textContent(node)
+			}
+			value = NewLiteral(v, temporalDatatype(v), "")
+			if value.Datatype == "" {
+				value.Language = language
+			}
+		default:
+			value = NewLiteral(textContent(node), "", language)
+		}
+
+		for _, prop := range p.attrIRIs(node, "property", local, false) {
+			if prop.Kind != IRI {
+				continue
+			}
+			if hasInlist {
+				list := lists.get(prop.Value)
+				list.members = append(list.members, value)
+			} else {
+				p.graph.add(*newSubject, prop, value)
+			}
+		}
+	}
+
+	// Step 12: complete the incomplete triples of the ancestors.
+	if !skip && newSubject != nil {
+		for _, inc := range ctx.incomplete {
+			switch inc.direction {
+			case rdfaNone:
+				inc.list.members = append(inc.list.members, *newSubject)
+			case rdfaForward:
+				p.graph.add(*ctx.parentSubject, NewIRI(inc.predicate), *newSubject)
+			case rdfaReverse:
+				p.graph.add(*newSubject, NewIRI(inc.predicate), *ctx.parentSubject)
+			}
+		}
+	}
+
+	// Step 13: the descendants.
+	var child *rdfaContext
+	if skip {
+		copied := *ctx
+		copied.language = language
+		copied.prefixes = prefixes
+		copied.vocab = vocab
+		child = &copied
+	} else {
+		child = &rdfaContext{
+			parentSubject: ctx.parentSubject,
+			prefixes:      prefixes,
+			incomplete:    incomplete,
+			lists:         lists,
+			language:      language,
+			vocab:         vocab,
+		}
+		if newSubject != nil {
+			child.parentSubject = newSubject
+		}
+		switch {
+		case currentObject != nil:
+			child.parentObject = currentObject
+		case newSubject != nil:
+			child.parentObject = newSubject
+		default:
+			child.parentObject = ctx.parentSubject
+		}
+	}
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		p.process(c, child)
+	}
+
+	// Step 14: the lists created on this element.
+	if listsCreated {
+		for _, predicate := range lists.predicates {
+			p.addList(*newSubject, NewIRI(predicate), lists.lists[predicate].members)
+		}
+	}
+}
+
+// addList adds the triples of an RDF list with the given members as value of the predicate.
+func (p *rdfaProcessor) addList(subject, predicate Term, members []Term) {
+	if len(members) == 0 {
+		p.graph.add(subject, predicate, NewIRI(rdfNil))
+		return
+	}
+
+	cells := make([]Term, len(members))
+	for i := range members {
+		cells[i] = *p.newBlankNode()
+	}
+	p.graph.add(subject, predicate, cells[0])
+	for i, member := range members {
+		p.graph.add(cells[i], NewIRI(rdfFirst), member)
+		if i+1 < len(cells) {
+			p.graph.add(cells[i], NewIRI(rdfRest), cells[i+1])
+		} else {
+			p.graph.add(cells[i], NewIRI(rdfRest), NewIRI(rdfNil))
+		}
+	}
+}
+
+// baseTerm returns the base IRI term.
+func (p *rdfaProcessor) baseTerm() *Term {
+	base := p.base
+	return &base
+}
+
+// newBlankNode returns a new, unique blank node.
+func (p *rdfaProcessor) newBlankNode() *Term {
+	t := NewBlankNode("b" + strconv.Itoa(p.next))
+	p.next++
+	return &t
+}
+
+// blankNode returns the blank node for a label used in the document.
+func (p *rdfaProcessor) blankNode(label string) *Term {
+	t, ok := p.bnodes[label]
+	if !ok {
+		t = *p.newBlankNode()
+		p.bnodes[label] = t
+	}
+	return &t
+}
+
+// resolve returns the IRI resolved against the base IRI.
+func (p *rdfaProcessor) resolve(iri string) string {
+	base, err := url.Parse(p.base.Value)
+	if err != nil {
+		return iri
+	}
+	u, err := base.Parse(iri)
+	if err != nil {
+		return iri
+	}
+	return u.String()
+}
+
+// attrIRI returns the IRI of an href or src attribute resolved against the base IRI.
+func (p *rdfaProcessor) attrIRI(node *html.Node, name string) *Term {
+	v, ok := getAttr(name, node)
+	if !ok {
+		return nil
+	}
+	t := NewIRI(p.resolve(strings.TrimSpace(v)))
+	return &t
+}
+
+// attrResource returns the resource of an about or resource attribute, a SafeCURIEorCURIEorIRI.
+func (p *rdfaProcessor) attrResource(node *html.Node, name string, ctx *rdfaContext) *Term {
+	v, ok := getAttr(name, node)
+	if !ok {
+		return nil
+	}
+	v = strings.TrimSpace(v)
+
+	if strings.HasPrefix(v, "[") && strings.HasSuffix(v, "]") {
+		curie := v[1 : len(v)-1]
+		if label, ok := strings.CutPrefix(curie, "_:"); ok {
+			return p.blankNode(label)
+		}
+		if iri, ok := p.expandCURIE(curie, ctx); ok {
+			t := NewIRI(iri)
+			return &t
+		}
+		return nil
+	}
+
+	if label, ok := strings.CutPrefix(v, "_:"); ok {
+		return p.blankNode(label)
+	}
+	if iri, ok := p.expandCURIE(v, ctx); ok {
+		t := NewIRI(iri)
+		return &t
+	}
+	t := NewIRI(p.resolve(v))
+	return &t
+}
+
+// attrIRIs returns the IRIs of a typeof, property, rel or rev attribute, a list of TERMorCURIEorAbsIRIs. It
+// returns nil if the attribute is absent or yields no IRIs. For rel and rev, following HTML+RDFa, terms are dropped
+// when the element also has a property attribute.
+func (p *rdfaProcessor) attrIRIs(node *html.Node, name string, ctx *rdfaContext, hasProperty bool) []Term {
+	v, ok := getAttr(name, node)
+	if !ok {
+		return nil
+	}
+
+	isLink := name == "rel" || name == "rev"
+	var result []Term
+	for _, token := range strings.Fields(v) {
+		if isLink && hasProperty && !strings.Contains(token, ":") {
+			continue
+		}
+		t := p.termOrIRI(token, ctx, name == "typeof")
+		if t == nil || (isLink && t.Kind != IRI) {
+			continue
+		}
+		result = append(result, *t)
+	}
+	if isLink && len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// termOrIRI returns the IRI of a term, compact IRI or absolute IRI. Blank nodes are only allowed when requested.
+func (p *rdfaProcessor) termOrIRI(value string, ctx *rdfaContext, allowBlankNode bool) *Term {
+	if value == "" {
+		return nil
+	}
+
+	if !strings.Contains(value, ":") {
+		if ctx.vocab != "" {
+			t := NewIRI(ctx.vocab + value)
+			return &t
+		}
+		for term, iri := range rdfaInitialTerms {
+			if strings.EqualFold(term, value) {
+				t := NewIRI(iri)
+				return &t
+			}
+		}
+		return nil
+	}
+
+	if label, ok := strings.CutPrefix(value, "_:"); ok {
+		if !allowBlankNode {
+			return nil
+		}
+		return p.blankNode(label)
+	}
+	if iri, ok := p.expandCURIE(value, ctx); ok {
+		t := NewIRI(iri)
+		return &t
+	}
+	if u, err := url.Parse(value); err == nil && u.Scheme != "" {
+		t := NewIRI(value)
+		return &t
+	}
+	return nil
+}
+
+// expandCURIE returns the IRI of a compact IRI with a known prefix. An empty prefix maps to the XHTML vocabulary.
+func (p *rdfaProcessor) expandCURIE(value string, ctx *rdfaContext) (string, bool) {
+	prefix, reference, ok := strings.Cut(value, ":")
+	if !ok || strings.HasPrefix(reference, "//") {
+		return "", false
+	}
+	if prefix == "" {
+		return xhvNS + reference, true
+	}
+
+	lite := rdfaLiteContext{prefixes: ctx.prefixes}
+	if ns, ok := lite.namespace(prefix); ok {
+		return ns + reference, true
+	}
+	return "", false
+}
+
+// sameTerm returns true if both terms are nil or equal.
+func sameTerm(a, b *Term) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// textContent returns the concatenated text of the node's descendants.
+func textContent(node *html.Node) string {
+	var buf bytes.Buffer
+	walkNodes(node, func(n *html.Node) {
+		if n.Type == html.TextNode {
+			buf.WriteString(n.Data)
+		}
+	})
+	return buf.String()
+}
+
+// innerHTML returns the rendered children of the node.
+func innerHTML(node *html.Node) string {
+	var buf bytes.Buffer
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		html.Render(&buf, c)
+	}
+	return buf.String()
+}
+
+var temporalPatterns = []struct {
+	pattern  *regexp.Regexp
+	datatype string
+}{
+	{regexp.MustCompile(`^-?P(\d+Y)?(\d+M)?(\d+D)?(T(\d+H)?(\d+M)?(\d+(\.\d+)?S)?)?$`), xsdNS + "duration"},
+	{regexp.MustCompile(`^-?\d{4,}-\d{2}-\d{2}T\d{2}:\d{2}(:\d{2}(\.\d+)?)?(Z|[+-]\d{2}:?\d{2})?$`), xsdNS + "dateTime"},
+	{regexp.MustCompile(`^-?\d{4,}-\d{2}-\d{2}(Z|[+-]\d{2}:?\d{2})?$`), xsdNS + "date"},
+	{regexp.MustCompile(`^\d{2}:\d{2}(:\d{2}(\.\d+)?)?(Z|[+-]\d{2}:?\d{2})?$`), xsdNS + "time"},
+	{regexp.MustCompile(`^-?\d{4,}-\d{2}$`), xsdNS + "gYearMonth"},
+	{regexp.MustCompile(`^-?\d{4,}$`), xsdNS + "gYear"},
+}
+
+// temporalDatatype returns the XML Schema datatype of the lexical form of a <time> value, or "" if it has none.
+func temporalDatatype(value string) string {
+	for _, p := range temporalPatterns {
+		if p.pattern.MatchString(value) {
+			return p.datatype
+		}
+	}
+	return ""
+}
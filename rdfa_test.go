@@ -0,0 +1,155 @@
+package microdata
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestParseRDFaLite(t *testing.T) {
+	html := `
+		<div vocab="https://schema.org/" typeof="Product" resource="#shoe" prefix="ov: http://open.vocab.org/terms/">
+			<span property="name">Running shoe</span>
+			<img property="image" src="shoe.jpg">
+			<span property="ov:color dc:description">Red</span>
+			<div property="offers" typeof="Offer">
+				<meta property="priceCurrency" content="EUR">
+				<span property="price">99.95</span>
+				<link property="availability" href="https://schema.org/InStock">
+			</div>
+		</div>
+		<p property="name">Outside of any item</p>`
+
+	data, err := ParseHTML(strings.NewReader(html), "text/html; charset=utf-8", "https://shop.example.com/shoes/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(b)
+	expected := `{"items":[{"type":["https://schema.org/Product"],"properties":{"http://open.vocab.org/terms/color":["Red"],"http://purl.org/dc/terms/description":["Red"],"image":["https://shop.example.com/shoes/shoe.jpg"],"name":["Running shoe"],"offers":[{"type":["https://schema.org/Offer"],"properties":{"availability":["https://schema.org/InStock"],"price":["99.95"],"priceCurrency":["EUR"]},"innerHTML":{"price":["99.95"]}}]},"innerHTML":{"http://open.vocab.org/terms/color":["Red"],"http://purl.org/dc/terms/description":["Red"],"name":["Running shoe"]},"id":"https://shop.example.com/shoes/#shoe"}]}`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+
+	if data.GetFirstOfSchemaType("Product") == nil {
+		t.Error("Result should have been found by GetFirstOfSchemaType")
+	}
+}
+
+func TestParseRDFa(t *testing.T) {
+	document := `<html prefix="ex: http://example.org/ns#"><head><title>Data</title></head><body>
+		<div about="#dataset" typeof="dcat:Dataset">
+			<h1 property="dc:title" lang="en">Air quality</h1>
+			<span property="dc:issued" datatype="xsd:date" content="2024-01-31">January 31st</span>
+			<time property="dc:modified" datetime="2024-02-01T10:00:00Z">February 1st</time>
+			<a rel="dcat:distribution" href="data.csv">CSV</a>
+			<div rel="dc:publisher">
+				<div typeof="foaf:Organization"><span property="foaf:name">Ministry</span></div>
+			</div>
+			<a rev="ex:describes" href="/about">About</a>
+			<ol>
+				<li property="ex:step" inlist>Collect</li>
+				<li property="ex:step" inlist>Publish</li>
+			</ol>
+			<a rel="license" href="https://creativecommons.org/licenses/by/4.0/">CC BY</a>
+		</div>
+	</body></html>`
+
+	tree, err := html.Parse(strings.NewReader(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph, err := ParseRDFa(tree, "https://data.example.gov/datasets/air")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<https://data.example.gov/datasets/air#dataset> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://www.w3.org/ns/dcat#Dataset> .
+<https://data.example.gov/datasets/air#dataset> <http://purl.org/dc/terms/title> "Air quality"@en .
+<https://data.example.gov/datasets/air#dataset> <http://purl.org/dc/terms/issued> "2024-01-31"^^<http://www.w3.org/2001/XMLSchema#date> .
+<https://data.example.gov/datasets/air#dataset> <http://purl.org/dc/terms/modified> "2024-02-01T10:00:00Z"^^<http://www.w3.org/2001/XMLSchema#dateTime> .
+<https://data.example.gov/datasets/air#dataset> <http://www.w3.org/ns/dcat#distribution> <https://data.example.gov/datasets/data.csv> .
+_:b1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://xmlns.com/foaf/0.1/Organization> .
+<https://data.example.gov/datasets/air#dataset> <http://purl.org/dc/terms/publisher> _:b1 .
+_:b1 <http://xmlns.com/foaf/0.1/name> "Ministry" .
+<https://data.example.gov/about> <http://example.org/ns#describes> <https://data.example.gov/datasets/air#dataset> .
+<https://data.example.gov/datasets/air#dataset> <http://www.w3.org/1999/xhtml/vocab#license> <https://creativecommons.org/licenses/by/4.0/> .
+<https://data.example.gov/datasets/air#dataset> <http://example.org/ns#step> _:b2 .
+_:b2 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> "Collect" .
+_:b2 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> _:b3 .
+_:b3 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> "Publish" .
+_:b3 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> <http://www.w3.org/1999/02/22-rdf-syntax-ns#nil> .
+`
+	if result := graph.String(); result != expected {
+		t.Errorf("Result should have been\n%s\nbut it was\n%s", expected, result)
+	}
+
+	b, err := json.Marshal(graph.Items())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedItems := `[{"type":["http://www.w3.org/ns/dcat#Dataset"],"properties":{"distribution":["https://data.example.gov/datasets/data.csv"],"http://example.org/ns#step":["Collect","Publish"],"http://purl.org/dc/terms/issued":["2024-01-31"],"http://purl.org/dc/terms/modified":["2024-02-01T10:00:00Z"],"http://purl.org/dc/terms/publisher":[{"type":["http://xmlns.com/foaf/0.1/Organization"],"properties":{"name":["Ministry"]}}],"http://purl.org/dc/terms/title":["Air quality"],"http://www.w3.org/1999/xhtml/vocab#license":["https://creativecommons.org/licenses/by/4.0/"]},"id":"https://data.example.gov/datasets/air#dataset"},{"type":[],"properties":{"http://example.org/ns#describes":["https://data.example.gov/datasets/air#dataset"]},"id":"https://data.example.gov/about"}]`
+	if result := string(b); result != expectedItems {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expectedItems, result)
+	}
+}
+
+func TestParseRDFaChaining(t *testing.T) {
+	// A rel without a resource completes with the subjects of the descendants, and @vocab is recorded.
+	document := `<div vocab="http://schema.org/" about="/people/alice" typeof="Person">
+		<div rel="knows">
+			<span about="/people/bob" property="name">Bob</span>
+			<span about="/people/carol" property="name">Carol</span>
+		</div>
+	</div>`
+
+	tree, err := html.Parse(strings.NewReader(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph, err := ParseRDFa(tree, "https://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<https://example.com/> <http://www.w3.org/ns/rdfa#usesVocabulary> <http://schema.org/> .
+<https://example.com/people/alice> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://schema.org/Person> .
+<https://example.com/people/bob> <http://schema.org/name> "Bob" .
+<https://example.com/people/alice> <http://schema.org/knows> <https://example.com/people/bob> .
+<https://example.com/people/carol> <http://schema.org/name> "Carol" .
+<https://example.com/people/alice> <http://schema.org/knows> <https://example.com/people/carol> .
+`
+	if result := graph.String(); result != expected {
+		t.Errorf("Result should have been\n%s\nbut it was\n%s", expected, result)
+	}
+}
+
+func TestParseRDFaBody(t *testing.T) {
+	// The body and head without a resource are about the document.
+	document := `<html><head><meta property="dc:title" content="Home"></head>
+		<body vocab="http://schema.org/" typeof="WebPage"><span property="name">X</span></body></html>`
+
+	tree, err := html.Parse(strings.NewReader(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph, err := ParseRDFa(tree, "https://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<https://example.com/> <http://purl.org/dc/terms/title> "Home" .
+<https://example.com/> <http://www.w3.org/ns/rdfa#usesVocabulary> <http://schema.org/> .
+<https://example.com/> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://schema.org/WebPage> .
+<https://example.com/> <http://schema.org/name> "X" .
+`
+	if result := graph.String(); result != expected {
+		t.Errorf("Result should have been\n%s\nbut it was\n%s", expected, result)
+	}
+}
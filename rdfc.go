@@ -0,0 +1,304 @@
+package microdata
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ErrCanonicalizationLimit is returned when canonicalizing a graph takes too many steps, which happens with graphs
+// of many blank nodes that are indistinguishable but for their names, crafted to exhaust the algorithm.
+var ErrCanonicalizationLimit = errors.New("microdata: rdfc: too many blank node permutations")
+
+// maxNDegreeHashes bounds the calls of the Hash N-Degree Quads algorithm of a canonicalization.
+const maxNDegreeHashes = 10000
+
+// Hash returns the hex-encoded SHA-256 of the canonical form of the items, see Graph.Hash. The hash doesn't
+// depend on the order of the items and properties, on the names of blank nodes, on whether the data was nested
+// or linked with IDs or on whether it was written as microdata or JSON-LD, so that it only changes when the data
+// does.
+func (m *Microdata) Hash(opts ...JSONLDOption) (string, error) {
+	g, err := m.RDF(opts...)
+	if err != nil {
+		return "", err
+	}
+	return g.Hash()
+}
+
+// Hash returns the hex-encoded SHA-256 of the canonical N-Triples of the graph, see Graph.Canonicalize.
+func (g *Graph) Hash() (string, error) {
+	c, err := g.Canonicalize()
+	if err != nil {
+		return "", err
+	}
+	return sha256Hex(c.String()), nil
+}
+
+// Canonicalize implements the RDF Dataset Canonicalization algorithm, RDFC-1.0 (formerly URDNA2015), see
+// https://www.w3.org/TR/rdf-canon/, with SHA-256. It returns the graph without duplicate triples, with its blank
+// nodes labeled c14n0, c14n1, etc., and with its triples sorted by their N-Triples form, so that equivalent graphs
+// have the same String. It returns ErrCanonicalizationLimit for graphs that take too many steps.
+func (g *Graph) Canonicalize() (*Graph, error) {
+	c := &canonicalizer{
+		quads:     make(map[string][]Triple),
+		firstHash: make(map[string]string),
+		canonical: newBlankNodeIssuer("_:c14n"),
+	}
+
+	var triples []Triple
+	seen := make(map[string]bool)
+	for _, t := range g.Triples {
+		if s := t.String(); !seen[s] {
+			seen[s] = true
+			triples = append(triples, t)
+		}
+	}
+
+	var labels []string
+	for _, t := range triples {
+		for _, term := range []Term{t.Subject, t.Object} {
+			if term.Kind != BlankNode {
+				continue
+			}
+			if _, ok := c.quads[term.Value]; !ok {
+				labels = append(labels, term.Value)
+			}
+			quads := c.quads[term.Value]
+			if len(quads) == 0 || quads[len(quads)-1] != t {
+				c.quads[term.Value] = append(quads, t)
+			}
+		}
+	}
+
+	hashToLabels := make(map[string][]string)
+	for _, label := range labels {
+		h := c.hashFirstDegree(label)
+		hashToLabels[h] = append(hashToLabels[h], label)
+	}
+
+	var nonUnique []string
+	for _, h := range sortedHashes(hashToLabels) {
+		if len(hashToLabels[h]) > 1 {
+			nonUnique = append(nonUnique, h)
+			continue
+		}
+		c.canonical.issue(hashToLabels[h][0])
+	}
+
+	for _, h := range nonUnique {
+		var results []nDegreeResult
+		for _, label := range hashToLabels[h] {
+			if _, ok := c.canonical.issued[label]; ok {
+				continue
+			}
+			issuer := newBlankNodeIssuer("_:b")
+			issuer.issue(label)
+			result, err := c.hashNDegree(label, issuer)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, result)
+		}
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].hash < results[j].hash
+		})
+		for _, result := range results {
+			for _, label := range result.issuer.order {
+				c.canonical.issue(label)
+			}
+		}
+	}
+
+	canonical := &Graph{Triples: make([]Triple, len(triples))}
+	for i, t := range triples {
+		canonical.Triples[i] = Triple{
+			Subject:   c.relabel(t.Subject),
+			Predicate: t.Predicate,
+			Object:    c.relabel(t.Object),
+		}
+	}
+	sort.Slice(canonical.Triples, func(i, j int) bool {
+		return canonical.Triples[i].String() < canonical.Triples[j].String()
+	})
+	return canonical, nil
+}
+
+// canonicalizer holds the state of the RDFC-1.0 algorithm. Blank nodes are keyed by their label.
+type canonicalizer struct {
+	// quads maps the blank nodes to the triples they occur in.
+	quads map[string][]Triple
+	// firstHash caches the first degree hashes of the blank nodes.
+	firstHash map[string]string
+	// canonical issues the canonical identifiers.
+	canonical *blankNodeIssuer
+	// calls counts the calls of hashNDegree, see maxNDegreeHashes.
+	calls int
+}
+
+// nDegreeResult is the result of the Hash N-Degree Quads algorithm.
+type nDegreeResult struct {
+	hash   string
+	issuer *blankNodeIssuer
+}
+
+// hashFirstDegree implements the Hash First Degree Quads algorithm, see
+// https://www.w3.org/TR/rdf-canon/#hash-1d-quads.
+func (c *canonicalizer) hashFirstDegree(label string) string {
+	if h, ok := c.firstHash[label]; ok {
+		return h
+	}
+	lines := make([]string, 0, len(c.quads[label]))
+	for _, t := range c.quads[label] {
+		t.Subject, t.Object = firstDegreeTerm(t.Subject, label), firstDegreeTerm(t.Object, label)
+		lines = append(lines, t.String()+"\n")
+	}
+	sort.Strings(lines)
+	h := sha256Hex(strings.Join(lines, ""))
+	c.firstHash[label] = h
+	return h
+}
+
+// firstDegreeTerm returns the blank node _:a for the reference blank node and _:z for other blank nodes.
+func firstDegreeTerm(term Term, label string) Term {
+	if term.Kind != BlankNode {
+		return term
+	}
+	if term.Value == label {
+		return NewBlankNode("a")
+	}
+	return NewBlankNode("z")
+}
+
+// hashRelated implements the Hash Related Blank Node algorithm, see
+// https://www.w3.org/TR/rdf-canon/#hash-related-blank-node.
+func (c *canonicalizer) hashRelated(related string, t Triple, issuer *blankNodeIssuer, position string) string {
+	id, ok := c.canonical.issued[related]
+	if !ok {
+		if id, ok = issuer.issued[related]; !ok {
+			id = c.hashFirstDegree(related)
+		}
+	}
+	input := position
+	if position != "g" {
+		input += t.Predicate.String()
+	}
+	return sha256Hex(input + id)
+}
+
+// hashNDegree implements the Hash N-Degree Quads algorithm, see https://www.w3.org/TR/rdf-canon/#hash-nd-quads.
+func (c *canonicalizer) hashNDegree(label string, issuer *blankNodeIssuer) (nDegreeResult, error) {
+	c.calls++
+	if c.calls > maxNDegreeHashes {
+		return nDegreeResult{}, ErrCanonicalizationLimit
+	}
+
+	hashToRelated := make(map[string][]string)
+	for _, t := range c.quads[label] {
+		for _, related := range []struct {
+			term     Term
+			position string
+		}{{t.Subject, "s"}, {t.Object, "o"}} {
+			if related.term.Kind == BlankNode && related.term.Value != label {
+				h := c.hashRelated(related.term.Value, t, issuer, related.position)
+				hashToRelated[h] = append(hashToRelated[h], related.term.Value)
+			}
+		}
+	}
+
+	var data strings.Builder
+	for _, h := range sortedHashes(hashToRelated) {
+		data.WriteString(h)
+		var chosenPath string
+		var chosenIssuer *blankNodeIssuer
+		err := permute(hashToRelated[h], func(permutation []string) error {
+			issuerCopy := issuer.copy()
+			path := ""
+			var recursion []string
+			for _, related := range permutation {
+				if id, ok := c.canonical.issued[related]; ok {
+					path += id
+				} else {
+					if _, ok := issuerCopy.issued[related]; !ok {
+						recursion = append(recursion, related)
+					}
+					path += issuerCopy.issue(related)
+				}
+				if chosenPath != "" && len(path) >= len(chosenPath) && path > chosenPath {
+					return nil
+				}
+			}
+
+			for _, related := range recursion {
+				result, err := c.hashNDegree(related, issuerCopy)
+				if err != nil {
+					return err
+				}
+				path += issuerCopy.issue(related) + "<" + result.hash + ">"
+				issuerCopy = result.issuer
+				if chosenPath != "" && len(path) >= len(chosenPath) && path > chosenPath {
+					return nil
+				}
+			}
+
+			if chosenPath == "" || path < chosenPath {
+				chosenPath, chosenIssuer = path, issuerCopy
+			}
+			return nil
+		})
+		if err != nil {
+			return nDegreeResult{}, err
+		}
+		data.WriteString(chosenPath)
+		issuer = chosenIssuer
+	}
+	return nDegreeResult{hash: sha256Hex(data.String()), issuer: issuer}, nil
+}
+
+// relabel returns the blank node with its canonical label, other terms as is.
+func (c *canonicalizer) relabel(term Term) Term {
+	if term.Kind != BlankNode {
+		return term
+	}
+	return NewBlankNode(strings.TrimPrefix(c.canonical.issued[term.Value], "_:"))
+}
+
+// permute calls f with every permutation of the list, stopping at the first error.
+func permute(list []string, f func([]string) error) error {
+	a := append([]string(nil), list...)
+	var generate func(k int) error
+	generate = func(k int) error {
+		if k <= 1 {
+			return f(a)
+		}
+		for i := 0; i < k; i++ {
+			if err := generate(k - 1); err != nil {
+				return err
+			}
+			if k%2 == 0 {
+				a[i], a[k-1] = a[k-1], a[i]
+			} else {
+				a[0], a[k-1] = a[k-1], a[0]
+			}
+		}
+		return nil
+	}
+	return generate(len(a))
+}
+
+// sortedHashes returns the keys of the map in code point order.
+func sortedHashes(m map[string][]string) []string {
+	hashes := make([]string, 0, len(m))
+	for h := range m {
+		hashes = append(hashes, h)
+	}
+	sort.Strings(hashes)
+	return hashes
+}
+
+// sha256Hex returns the hex-encoded SHA-256 of s.
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
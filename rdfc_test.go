@@ -0,0 +1,153 @@
+package microdata
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	p, q, r, s := NewIRI("http://example.com/#p"), NewIRI("http://example.com/#q"), NewIRI("http://example.com/#r"),
+		NewIRI("http://example.com/#s")
+	u, tt := NewIRI("http://example.com/#u"), NewIRI("http://example.com/#t")
+	e0, e1, e2, e3 := NewBlankNode("e0"), NewBlankNode("e1"), NewBlankNode("e2"), NewBlankNode("e3")
+
+	// The examples of https://www.w3.org/TR/rdf-canon/, with unique and shared first degree hashes.
+	tests := []struct {
+		graph    Graph
+		expected string
+	}{
+		{
+			Graph{Triples: []Triple{
+				{p, q, e0},
+				{p, r, e1},
+				{e0, s, u},
+				{e1, tt, u},
+			}},
+			`<http://example.com/#p> <http://example.com/#q> _:c14n0 .
+<http://example.com/#p> <http://example.com/#r> _:c14n1 .
+_:c14n0 <http://example.com/#s> <http://example.com/#u> .
+_:c14n1 <http://example.com/#t> <http://example.com/#u> .
+`,
+		},
+		{
+			Graph{Triples: []Triple{
+				{p, q, e0},
+				{p, q, e1},
+				{e0, p, e2},
+				{e1, p, e3},
+				{e2, r, e3},
+			}},
+			`<http://example.com/#p> <http://example.com/#q> _:c14n2 .
+<http://example.com/#p> <http://example.com/#q> _:c14n3 .
+_:c14n0 <http://example.com/#r> _:c14n1 .
+_:c14n2 <http://example.com/#p> _:c14n1 .
+_:c14n3 <http://example.com/#p> _:c14n0 .
+`,
+		},
+	}
+
+	for _, test := range tests {
+		c, err := test.graph.Canonicalize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result := c.String(); result != test.expected {
+			t.Errorf("Result should have been \"%s\", but it was \"%s\"", test.expected, result)
+		}
+	}
+}
+
+func TestCanonicalizeLimit(t *testing.T) {
+	// A clique of blank nodes that can only be told apart by trying all permutations.
+	g := &Graph{}
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 10; j++ {
+			if i != j {
+				g.add(NewBlankNode(string(rune('a'+i))), NewIRI("http://example.com/#p"),
+					NewBlankNode(string(rune('a'+j))))
+			}
+		}
+	}
+	if _, err := g.Canonicalize(); !errors.Is(err, ErrCanonicalizationLimit) {
+		t.Errorf("Result should have been ErrCanonicalizationLimit, but it was %v", err)
+	}
+}
+
+func TestHash(t *testing.T) {
+	pages := []string{
+		`<script type="application/ld+json">{
+			"@context": "https://schema.org",
+			"@type": "Product",
+			"name": "Shoe",
+			"offers": {"@type": "Offer", "price": "10", "priceCurrency": "EUR"}
+		}</script>`,
+		`<script type="application/ld+json">{
+			"@context": "https://schema.org",
+			"@graph": [
+				{"@id": "_:offer", "priceCurrency": "EUR", "@type": "Offer", "price": "10"},
+				{"offers": {"@id": "_:offer"}, "name": "Shoe", "@type": "Product"}
+			]
+		}</script>`,
+		`<div itemscope itemtype="https://schema.org/Product">
+			<span itemprop="name">Shoe</span>
+			<div itemprop="offers" itemscope itemtype="https://schema.org/Offer">
+				<meta itemprop="priceCurrency" content="EUR"><span itemprop="price">10</span>
+			</div>
+		</div>`,
+	}
+
+	var hashes []string
+	for _, page := range pages {
+		hash, err := ParseData(page, t).Hash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash)
+	}
+	for i, hash := range hashes[1:] {
+		if hash != hashes[0] {
+			t.Errorf("Result should have been \"%s\", but it was \"%s\" for page %d", hashes[0], hash, i+1)
+		}
+	}
+
+	changed, err := ParseData(strings.Replace(pages[0], `"10"`, `"11"`, 1), t).Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed == hashes[0] {
+		t.Errorf("Result should have been another hash than \"%s\"", changed)
+	}
+}
+
+func TestHashURL(t *testing.T) {
+	jsonld := ParseData(`<script type="application/ld+json">{
+		"@context": "https://schema.org",
+		"@type": "Product",
+		"url": "https://example.com/shoe",
+		"image": "https://example.com/shoe.jpg"
+	}</script>`, t)
+	md := ParseData(`<div itemscope itemtype="https://schema.org/Product">
+		<a itemprop="url" href="/shoe">Shoe</a><img itemprop="image" src="shoe.jpg">
+	</div>`, t)
+
+	var hashes []string
+	for _, data := range []*Microdata{jsonld, md} {
+		g, err := data.RDF()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := "_:b0 <http://schema.org/url> <https://example.com/shoe> .\n"
+		if result := g.String(); !strings.Contains(result, expected) {
+			t.Errorf("Result should have contained \"%s\", but it was \"%s\"", expected, result)
+		}
+		hash, err := data.Hash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash)
+	}
+	if hashes[0] != hashes[1] {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", hashes[0], hashes[1])
+	}
+}
@@ -0,0 +1,152 @@
+package microdata
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robotsRules holds the robots.txt rules that apply to a single user agent, see RFC 9309.
+type robotsRules struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// robotsGroup is a group of rules in a robots.txt file with the user agents it applies to.
+type robotsGroup struct {
+	agents []string
+	robotsRules
+}
+
+var allowAll = &robotsRules{}
+
+var disallowAll = &robotsRules{rules: []robotsRule{{allow: false, pattern: "/"}}}
+
+// parseRobots reads the robots.txt content in r and returns the rules for the given user agent. The user agent is
+// reduced to its product token, e.g. "microdata" for "microdata/1.0 (+https://...)".
+func parseRobots(r io.Reader, userAgent string) *robotsRules {
+	agent := strings.ToLower(userAgent)
+	if i := strings.IndexAny(agent, "/ "); i >= 0 {
+		agent = agent[:i]
+	}
+
+	var groups []*robotsGroup
+	var group *robotsGroup
+	inAgents := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				group = &robotsGroup{}
+				groups = append(groups, group)
+				inAgents = true
+			}
+			group.agents = append(group.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			if group != nil && value != "" {
+				group.rules = append(group.rules, robotsRule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			inAgents = false
+			if group != nil {
+				if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+					group.crawlDelay = time.Duration(seconds * float64(time.Second))
+				}
+			}
+		}
+	}
+
+	// Merge the groups naming the agent, or the ones for "*" when there are none.
+	var matched, wildcard robotsRules
+	found := false
+	for _, g := range groups {
+		for _, a := range g.agents {
+			switch a {
+			case agent:
+				matched.merge(&g.robotsRules)
+				found = true
+			case "*":
+				wildcard.merge(&g.robotsRules)
+			}
+		}
+	}
+	if found {
+		return &matched
+	}
+	return &wildcard
+}
+
+// merge adds the rules of other to r.
+func (r *robotsRules) merge(other *robotsRules) {
+	r.rules = append(r.rules, other.rules...)
+	if other.crawlDelay > r.crawlDelay {
+		r.crawlDelay = other.crawlDelay
+	}
+}
+
+// allowed returns true if the given path, including the query, may be crawled. The longest matching rule wins and
+// allow rules win ties.
+func (r *robotsRules) allowed(path string) bool {
+	if path == "/robots.txt" {
+		return true
+	}
+
+	allow, length := true, -1
+	for _, rule := range r.rules {
+		if len(rule.pattern) < length || !matchRobotsPattern(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > length || rule.allow {
+			allow, length = rule.allow, len(rule.pattern)
+		}
+	}
+	return allow
+}
+
+// matchRobotsPattern returns true if the path starts with the pattern, where "*" matches any sequence of characters
+// and a trailing "$" anchors the pattern at the end of the path.
+func matchRobotsPattern(pattern, path string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			pattern = pattern[1:]
+			for i := 0; i <= len(path); i++ {
+				if matchRobotsPattern(pattern, path[i:]) {
+					return true
+				}
+			}
+			return false
+		case '$':
+			if len(pattern) == 1 {
+				return len(path) == 0
+			}
+		}
+
+		if len(path) == 0 || pattern[0] != path[0] {
+			return false
+		}
+		pattern, path = pattern[1:], path[1:]
+	}
+	return true
+}
@@ -0,0 +1,275 @@
+package microdata
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSitemapWorkers is the number of pages fetched concurrently by a SitemapExtractor unless configured.
+const DefaultSitemapWorkers = 4
+
+// Sitemap is a parsed sitemap (urlset) or sitemap index file.
+type Sitemap struct {
+	// URLs holds the <url> entries of a urlset.
+	URLs []SitemapEntry
+	// Sitemaps holds the <sitemap> entries of a sitemap index.
+	Sitemaps []SitemapEntry
+}
+
+// SitemapEntry is a <url> or <sitemap> entry of a sitemap file.
+type SitemapEntry struct {
+	Loc        string
+	LastMod    time.Time
+	ChangeFreq string
+	Priority   string
+}
+
+// SitemapResult is the outcome of extracting the microdata of a single sitemap location. When a sitemap file itself
+// cannot be read, URL is the location of that file and Err is set.
+type SitemapResult struct {
+	URL       string
+	LastMod   time.Time
+	Microdata *Microdata
+	Err       error
+}
+
+type sitemapXML struct {
+	URLs     []sitemapEntryXML `xml:"url"`
+	Sitemaps []sitemapEntryXML `xml:"sitemap"`
+}
+
+type sitemapEntryXML struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod"`
+	ChangeFreq string `xml:"changefreq"`
+	Priority   string `xml:"priority"`
+}
+
+// lastModLayouts are the W3C Datetime formats allowed in <lastmod>.
+var lastModLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// ParseSitemap reads a plain or gzip compressed sitemap or sitemap index file.
+func ParseSitemap(r io.Reader) (*Sitemap, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	} else {
+		r = br
+	}
+
+	var doc sitemapXML
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return &Sitemap{
+		URLs:     readSitemapEntries(doc.URLs),
+		Sitemaps: readSitemapEntries(doc.Sitemaps),
+	}, nil
+}
+
+// readSitemapEntries converts the XML entries, dropping the ones without location.
+func readSitemapEntries(entries []sitemapEntryXML) []SitemapEntry {
+	var result []SitemapEntry
+	for _, e := range entries {
+		loc := strings.TrimSpace(e.Loc)
+		if loc == "" {
+			continue
+		}
+
+		entry := SitemapEntry{
+			Loc:        loc,
+			ChangeFreq: strings.TrimSpace(e.ChangeFreq),
+			Priority:   strings.TrimSpace(e.Priority),
+		}
+		lastMod := strings.TrimSpace(e.LastMod)
+		for _, layout := range lastModLayouts {
+			if t, err := time.Parse(layout, lastMod); err == nil {
+				entry.LastMod = t
+				break
+			}
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// SitemapExtractor extracts the microdata of every location listed in sitemaps using a bounded pool of workers.
+type SitemapExtractor struct {
+	// Fetcher fetches sitemap files and pages. NewFetcher() is used when nil.
+	Fetcher *Fetcher
+	// Workers is the number of pages fetched concurrently. DefaultSitemapWorkers is used when <= 0.
+	Workers int
+	// Since skips entries with a <lastmod> that is not after it. Entries without <lastmod> are never skipped.
+	Since time.Time
+	// Skip, when set, is called for every page entry and skips it when it returns true.
+	Skip func(SitemapEntry) bool
+}
+
+// Extract reads the sitemap or sitemap index at the given URL, following nested sitemaps, and streams the results
+// of every page over the returned channel. The channel is closed when all pages are done or ctx is canceled.
+func (s *SitemapExtractor) Extract(ctx context.Context, sitemapURL string) <-chan SitemapResult {
+	return s.start(ctx, func(w *sitemapWalker) {
+		w.readURL(sitemapURL)
+	})
+}
+
+// ExtractReader reads the sitemap or sitemap index from r, resolving relative locations against baseURL, and
+// streams the results of every page over the returned channel like Extract.
+func (s *SitemapExtractor) ExtractReader(ctx context.Context, r io.Reader, baseURL string) <-chan SitemapResult {
+	return s.start(ctx, func(w *sitemapWalker) {
+		w.read(r, baseURL)
+	})
+}
+
+// start runs the walk producing page entries and the workers consuming them.
+func (s *SitemapExtractor) start(ctx context.Context, walk func(*sitemapWalker)) <-chan SitemapResult {
+	fetcher := s.Fetcher
+	if fetcher == nil {
+		fetcher = NewFetcher()
+	}
+	workers := s.Workers
+	if workers <= 0 {
+		workers = DefaultSitemapWorkers
+	}
+
+	jobs := make(chan SitemapEntry)
+	results := make(chan SitemapResult)
+	w := &sitemapWalker{
+		ctx:      ctx,
+		fetcher:  fetcher,
+		extract:  s,
+		jobs:     jobs,
+		results:  results,
+		visited:  make(map[string]bool),
+		sitemaps: make(map[string]bool),
+	}
+
+	go func() {
+		defer close(jobs)
+		walk(w)
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range jobs {
+				data, err := fetcher.ParseURL(ctx, entry.Loc)
+				w.send(SitemapResult{URL: entry.Loc, LastMod: entry.LastMod, Microdata: data, Err: err})
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// skip returns true if the given entry is unchanged according to the extractor's settings.
+func (s *SitemapExtractor) skip(entry SitemapEntry) bool {
+	if !s.Since.IsZero() && !entry.LastMod.IsZero() && !entry.LastMod.After(s.Since) {
+		return true
+	}
+	return s.Skip != nil && s.Skip(entry)
+}
+
+// sitemapWalker reads sitemap files and queues their page entries.
+type sitemapWalker struct {
+	ctx      context.Context
+	fetcher  *Fetcher
+	extract  *SitemapExtractor
+	jobs     chan<- SitemapEntry
+	results  chan<- SitemapResult
+	visited  map[string]bool
+	sitemaps map[string]bool
+}
+
+// readURL fetches and reads the sitemap at the given URL, once.
+func (w *sitemapWalker) readURL(sitemapURL string) {
+	if w.sitemaps[sitemapURL] || w.ctx.Err() != nil {
+		return
+	}
+	w.sitemaps[sitemapURL] = true
+
+	resp, body, err := w.fetcher.get(w.ctx, sitemapURL)
+	if err != nil {
+		w.send(SitemapResult{URL: sitemapURL, Err: err})
+		return
+	}
+	defer body.Close()
+
+	w.read(body, resp.URL)
+}
+
+// read reads the sitemap in r, queues its pages and follows its nested sitemaps.
+func (w *sitemapWalker) read(r io.Reader, baseURL string) {
+	sitemap, err := ParseSitemap(r)
+	if err != nil {
+		w.send(SitemapResult{URL: baseURL, Err: err})
+		return
+	}
+
+	base, _ := url.Parse(baseURL)
+	for _, entry := range sitemap.URLs {
+		entry.Loc = resolveLoc(base, entry.Loc)
+		if w.visited[entry.Loc] || w.extract.skip(entry) {
+			continue
+		}
+		w.visited[entry.Loc] = true
+
+		select {
+		case w.jobs <- entry:
+		case <-w.ctx.Done():
+			return
+		}
+	}
+
+	for _, entry := range sitemap.Sitemaps {
+		if !w.extract.Since.IsZero() && !entry.LastMod.IsZero() && !entry.LastMod.After(w.extract.Since) {
+			continue
+		}
+		w.readURL(resolveLoc(base, entry.Loc))
+	}
+}
+
+// send delivers the result unless ctx is canceled.
+func (w *sitemapWalker) send(result SitemapResult) {
+	select {
+	case w.results <- result:
+	case <-w.ctx.Done():
+	}
+}
+
+// resolveLoc returns loc resolved against base, or loc itself when it cannot be resolved.
+func resolveLoc(base *url.URL, loc string) string {
+	if base == nil {
+		return loc
+	}
+	if u, err := base.Parse(loc); err == nil {
+		return u.String()
+	}
+	return loc
+}
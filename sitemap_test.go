@@ -0,0 +1,105 @@
+package microdata
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSitemap(t *testing.T) {
+	sitemap := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc> https://example.com/a </loc>
+    <lastmod>2024-03-01</lastmod>
+    <changefreq>daily</changefreq>
+  </url>
+  <url>
+    <loc>https://example.com/b</loc>
+    <lastmod>2024-03-02T10:30:00+01:00</lastmod>
+  </url>
+</urlset>`
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write([]byte(sitemap))
+	_ = zw.Close()
+
+	for _, r := range []*bytes.Reader{bytes.NewReader([]byte(sitemap)), bytes.NewReader(buf.Bytes())} {
+		result, err := ParseSitemap(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(result.URLs) != 2 {
+			t.Fatalf("Result should have been 2 URLs, but it was %d", len(result.URLs))
+		}
+		if result.URLs[0].Loc != "https://example.com/a" {
+			t.Errorf("Result should have been \"https://example.com/a\", but it was \"%s\"", result.URLs[0].Loc)
+		}
+		if result.URLs[0].ChangeFreq != "daily" {
+			t.Errorf("Result should have been \"daily\", but it was \"%s\"", result.URLs[0].ChangeFreq)
+		}
+		expected := time.Date(2024, 3, 2, 9, 30, 0, 0, time.UTC)
+		if !result.URLs[1].LastMod.Equal(expected) {
+			t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result.URLs[1].LastMod)
+		}
+	}
+}
+
+func TestSitemapExtractor(t *testing.T) {
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	mux.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+			<sitemap><loc>%[1]s/pages.xml.gz</loc></sitemap>
+			<sitemap><loc>%[1]s/old.xml</loc><lastmod>2020-01-01</lastmod></sitemap>
+			<sitemap><loc>%[1]s/missing.xml</loc></sitemap>
+		</sitemapindex>`, ts.URL)
+	})
+	mux.HandleFunc("/pages.xml.gz", func(w http.ResponseWriter, r *http.Request) {
+		zw := gzip.NewWriter(w)
+		fmt.Fprintf(zw, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+			<url><loc>%[1]s/page/1</loc><lastmod>2024-05-01</lastmod></url>
+			<url><loc>%[1]s/page/2</loc></url>
+			<url><loc>/page/3</loc><lastmod>2021-05-01</lastmod></url>
+			<url><loc>%[1]s/page/1</loc></url>
+		</urlset>`, ts.URL)
+		_ = zw.Close()
+	})
+	mux.HandleFunc("/old.xml", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Sitemap older than Since should not have been fetched")
+	})
+	mux.HandleFunc("/page/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<div itemscope itemtype="https://schema.org/WebPage"><span itemprop="name">%s</span></div>`, r.URL.Path)
+	})
+
+	extractor := &SitemapExtractor{Workers: 2, Since: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	var pages, failures []string
+	for result := range extractor.Extract(context.Background(), ts.URL+"/sitemap.xml") {
+		if result.Err != nil {
+			failures = append(failures, strings.TrimPrefix(result.URL, ts.URL))
+			continue
+		}
+		name := result.Microdata.Items[0].Properties["name"][0].(string)
+		pages = append(pages, name)
+	}
+	sort.Strings(pages)
+
+	if expected := "/page/1 /page/2"; strings.Join(pages, " ") != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, strings.Join(pages, " "))
+	}
+	if expected := "/missing.xml"; strings.Join(failures, " ") != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, strings.Join(failures, " "))
+	}
+}
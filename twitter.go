@@ -0,0 +1,177 @@
+package microdata
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// TwitterCard holds the Twitter (X) card metadata of a document, see
+// https://developer.x.com/en/docs/x-for-websites/cards/overview/markup. When a property occurs more than once, the
+// first occurrence is used.
+type TwitterCard struct {
+	Card        string `json:"card,omitempty"`
+	Site        string `json:"site,omitempty"`
+	SiteID      string `json:"siteId,omitempty"`
+	Creator     string `json:"creator,omitempty"`
+	CreatorID   string `json:"creatorId,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Image       string `json:"image,omitempty"`
+	ImageAlt    string `json:"imageAlt,omitempty"`
+
+	Player *TwitterPlayer `json:"player,omitempty"`
+	App    *TwitterApp    `json:"app,omitempty"`
+}
+
+// TwitterPlayer holds the twitter:player properties of a player card.
+type TwitterPlayer struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+	Stream string `json:"stream,omitempty"`
+}
+
+// TwitterApp holds the twitter:app properties of an app card.
+type TwitterApp struct {
+	Country    string           `json:"country,omitempty"`
+	IPhone     *TwitterAppStore `json:"iphone,omitempty"`
+	IPad       *TwitterAppStore `json:"ipad,omitempty"`
+	GooglePlay *TwitterAppStore `json:"googleplay,omitempty"`
+}
+
+// TwitterAppStore holds the app name, ID and URL of an app in a store.
+type TwitterAppStore struct {
+	Name string `json:"name,omitempty"`
+	ID   string `json:"id,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+// twitterProperty is a property found in a <meta name="twitter:..."> element.
+type twitterProperty struct {
+	name, content string
+}
+
+// metaTwitterProperty returns the Twitter card property of a <meta name content> element. The legacy property and
+// value attributes are accepted too.
+func metaTwitterProperty(n *html.Node) (twitterProperty, bool) {
+	name, ok := getAttr("name", n)
+	if !ok || !strings.HasPrefix(strings.TrimSpace(name), "twitter:") {
+		name, ok = getAttr("property", n)
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !ok || !strings.HasPrefix(name, "twitter:") {
+		return twitterProperty{}, false
+	}
+
+	content, ok := getAttr("content", n)
+	if !ok {
+		if content, ok = getAttr("value", n); !ok {
+			return twitterProperty{}, false
+		}
+	}
+	return twitterProperty{name: name, content: strings.TrimSpace(content)}, true
+}
+
+// readTwitterCard returns the Twitter card metadata of the given properties, or nil if there are none. The title,
+// description and image fall back to the given Open Graph metadata, like the card processor does.
+func (p *parser) readTwitterCard(props []twitterProperty, og *OpenGraph) *TwitterCard {
+	if len(props) == 0 {
+		return nil
+	}
+
+	card := &TwitterCard{}
+	for _, prop := range props {
+		v := prop.content
+		switch prop.name {
+		case "twitter:card":
+			setFirst(&card.Card, v)
+		case "twitter:site":
+			setFirst(&card.Site, v)
+		case "twitter:site:id":
+			setFirst(&card.SiteID, v)
+		case "twitter:creator":
+			setFirst(&card.Creator, v)
+		case "twitter:creator:id":
+			setFirst(&card.CreatorID, v)
+		case "twitter:title":
+			setFirst(&card.Title, v)
+		case "twitter:description":
+			setFirst(&card.Description, v)
+		case "twitter:image", "twitter:image:src":
+			setFirst(&card.Image, p.resolveURL(v))
+		case "twitter:image:alt":
+			setFirst(&card.ImageAlt, v)
+		case "twitter:player":
+			setFirst(&card.player().URL, p.resolveURL(v))
+		case "twitter:player:width":
+			setFirstInt(&card.player().Width, v)
+		case "twitter:player:height":
+			setFirstInt(&card.player().Height, v)
+		case "twitter:player:stream":
+			setFirst(&card.player().Stream, p.resolveURL(v))
+		case "twitter:app:country":
+			setFirst(&card.app().Country, v)
+		default:
+			if name, ok := strings.CutPrefix(prop.name, "twitter:app:"); ok {
+				card.app().set(name, v)
+			}
+		}
+	}
+
+	if og != nil {
+		setFirst(&card.Title, og.Title)
+		setFirst(&card.Description, og.Description)
+		if card.Image == "" && len(og.Images) > 0 {
+			card.Image = og.Images[0].URL
+			setFirst(&card.ImageAlt, og.Images[0].Alt)
+		}
+	}
+	return card
+}
+
+func (c *TwitterCard) player() *TwitterPlayer {
+	if c.Player == nil {
+		c.Player = &TwitterPlayer{}
+	}
+	return c.Player
+}
+
+func (c *TwitterCard) app() *TwitterApp {
+	if c.App == nil {
+		c.App = &TwitterApp{}
+	}
+	return c.App
+}
+
+// set sets a twitter:app property given without its prefix, e.g. "name:iphone" or "id:googleplay".
+func (a *TwitterApp) set(name, value string) {
+	field, store, ok := strings.Cut(name, ":")
+	if !ok || (field != "name" && field != "id" && field != "url") {
+		return
+	}
+
+	var s **TwitterAppStore
+	switch store {
+	case "iphone":
+		s = &a.IPhone
+	case "ipad":
+		s = &a.IPad
+	case "googleplay":
+		s = &a.GooglePlay
+	default:
+		return
+	}
+	if *s == nil {
+		*s = &TwitterAppStore{}
+	}
+
+	switch field {
+	case "name":
+		setFirst(&(*s).Name, value)
+	case "id":
+		setFirst(&(*s).ID, value)
+	case "url":
+		setFirst(&(*s).URL, value)
+	}
+}
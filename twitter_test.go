@@ -0,0 +1,48 @@
+package microdata
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseTwitterCard(t *testing.T) {
+	html := `<html><head>
+		<meta name="twitter:card" content="player">
+		<meta name="twitter:site" content="@example">
+		<meta property="twitter:creator" content="@author">
+		<meta name="twitter:title" content="Twitter title">
+		<meta name="twitter:player" content="/embed/1">
+		<meta name="twitter:player:width" content="480">
+		<meta name="twitter:player:height" content="270">
+		<meta name="twitter:app:name:iphone" content="Example">
+		<meta name="twitter:app:id:iphone" content="123">
+		<meta name="twitter:app:id:googleplay" value="com.example">
+		<meta property="og:title" content="OG title">
+		<meta property="og:description" content="OG description">
+		<meta property="og:image" content="/og.png">
+		<meta property="og:image:alt" content="OG image">
+	</head></html>`
+
+	data, err := ParseHTML(strings.NewReader(html), "text/html; charset=utf-8", "https://example.com/videos/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(data.Twitter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := string(b)
+	expected := `{"card":"player","site":"@example","creator":"@author","title":"Twitter title","description":"OG description","image":"https://example.com/og.png","imageAlt":"OG image","player":{"url":"https://example.com/embed/1","width":480,"height":270},"app":{"iphone":{"name":"Example","id":"123"},"googleplay":{"id":"com.example"}}}`
+	if result != expected {
+		t.Errorf("Result should have been \"%s\", but it was \"%s\"", expected, result)
+	}
+}
+
+func TestParseNoTwitterCard(t *testing.T) {
+	data := ParseData(`<meta property="og:title" content="OG title">`, t)
+	if data.Twitter != nil {
+		t.Errorf("Result should have been no Twitter card, but it was %+v", data.Twitter)
+	}
+}
@@ -12,16 +12,6 @@ func getAttr(attribute string, node *html.Node) (string, bool) {
 	return "", false
 }
 
-// checkAttr returns true if the given node has the attribute with the expected value.
-func checkAttr(attribute, expectedValue string, node *html.Node) bool {
-	for _, a := range node.Attr {
-		if a.Key == attribute && a.Val == expectedValue {
-			return true
-		}
-	}
-	return false
-}
-
 // walkNodes traverses the node tree executing the given functions.
 func walkNodes(n *html.Node, f func(*html.Node)) {
 	if n != nil {
@@ -31,3 +21,19 @@ func walkNodes(n *html.Node, f func(*html.Node)) {
 		}
 	}
 }
+
+// findNode returns the first node in document order for which the given function returns true, or nil.
+func findNode(n *html.Node, f func(*html.Node) bool) *html.Node {
+	if n == nil {
+		return nil
+	}
+	if f(n) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findNode(c, f); found != nil {
+			return found
+		}
+	}
+	return nil
+}
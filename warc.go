@@ -0,0 +1,140 @@
+package microdata
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record is an HTML document read from an archive together with its microdata.
+type Record struct {
+	// URL is the original URL of the document. It is used to resolve the URLs in the document.
+	URL         string     `json:"url"`
+	Date        time.Time  `json:"date"`
+	StatusCode  int        `json:"statusCode,omitempty"`
+	ContentType string     `json:"contentType,omitempty"`
+	Microdata   *Microdata `json:"microdata,omitempty"`
+	// Err is set when the document could not be parsed.
+	Err error `json:"-"`
+}
+
+// ParseWARC reads the WARC/1.0 or WARC/1.1 file in r, plain or gzip compressed per record, and calls fn with the
+// microdata of every HTML response record. The WARC-Target-URI is used as the base URL and the Content-Type of the
+// archived HTTP response to decode the payload. Other record types, responses that aren't HTTP ones, e.g. of dns:
+// URIs, and non-HTML payloads are skipped. An error is returned when the file is malformed, errors of single records
+// are reported in Record.Err. The documents are parsed with the given options.
+func ParseWARC(r io.Reader, fn func(Record), opts ...ParseOption) error {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		// gzip.Reader reads concatenated members as a single stream.
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		br = bufio.NewReader(zr)
+	}
+
+	tp := textproto.NewReader(br)
+	for {
+		header, err := readWARCHeader(tp)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+		if err != nil || length < 0 {
+			return fmt.Errorf("microdata: invalid WARC Content-Length %q", header.Get("Content-Length"))
+		}
+
+		block := io.LimitReader(br, length)
+		mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+		if strings.EqualFold(header.Get("WARC-Type"), "response") && mediaType == "application/http" {
+			if record, ok := readWARCResponse(header, block, opts); ok {
+				fn(record)
+			}
+		}
+		if _, err := io.Copy(io.Discard, block); err != nil {
+			return err
+		}
+	}
+}
+
+// readWARCHeader reads the version line and the named fields of the next record, skipping the blank lines that
+// terminate the previous record.
+func readWARCHeader(tp *textproto.Reader) (textproto.MIMEHeader, error) {
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "WARC/") {
+			return nil, fmt.Errorf("microdata: invalid WARC version line %q", line)
+		}
+		break
+	}
+
+	header, err := tp.ReadMIMEHeader()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return header, err
+}
+
+// readWARCResponse parses the HTTP response in the block of a response record. It returns false when the payload is
+// not an HTML document.
+func readWARCResponse(header textproto.MIMEHeader, block io.Reader, opts []ParseOption) (Record, bool) {
+	record := Record{
+		URL: strings.Trim(header.Get("WARC-Target-URI"), "<>"),
+	}
+	if date, err := time.Parse(time.RFC3339Nano, header.Get("WARC-Date")); err == nil {
+		record.Date = date
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(block), nil)
+	if err != nil {
+		record.Err = err
+		return record, true
+	}
+	defer resp.Body.Close()
+
+	record.StatusCode = resp.StatusCode
+	record.ContentType = resp.Header.Get("Content-Type")
+
+	var body io.Reader = resp.Body
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			record.Err = err
+			return record, true
+		}
+		defer zr.Close()
+		body = zr
+	}
+
+	body, err = checkHTML(body, record.ContentType)
+	if errors.Is(err, ErrNotHTML) {
+		return record, false
+	}
+	if err != nil {
+		record.Err = err
+		return record, true
+	}
+
+	record.Microdata, record.Err = ParseHTML(body, record.ContentType, record.URL, opts...)
+	return record, true
+}
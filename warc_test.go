@@ -0,0 +1,75 @@
+package microdata
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// warcRecord returns a WARC/1.1 record with the given type, target URI and block.
+func warcRecord(warcType, uri, block string) string {
+	return fmt.Sprintf("WARC/1.1\r\nWARC-Type: %s\r\nWARC-Target-URI: %s\r\nWARC-Date: 2024-05-01T12:00:00Z\r\n"+
+		"Content-Type: application/http; msgtype=%s\r\nContent-Length: %d\r\n\r\n%s\r\n\r\n",
+		warcType, uri, warcType, len(block), block)
+}
+
+var warcRecords = []string{
+	"WARC/1.1\r\nWARC-Type: warcinfo\r\nContent-Length: 12\r\n\r\nsoftware: x\n\r\n\r\n",
+	warcRecord("request", "https://example.com/person", "GET /person HTTP/1.1\r\nHost: example.com\r\n\r\n"),
+	warcRecord("response", "https://example.com/person", "HTTP/1.1 200 OK\r\nContent-Type: text/html; charset=utf-8\r\n\r\n"+
+		`<div itemscope itemtype="https://schema.org/Person"><span itemprop="name">Penelope</span><a itemprop="url" href="/penelope">me</a></div>`),
+	"WARC/1.1\r\nWARC-Type: response\r\nWARC-Target-URI: dns:example.com\r\nContent-Type: text/dns\r\n" +
+		"Content-Length: 38\r\n\r\n20240501120000\nexample.com. 60 IN A 1\n\r\n\r\n",
+	warcRecord("response", "https://example.com/logo.png", "HTTP/1.1 200 OK\r\nContent-Type: image/png\r\n\r\n\x89PNG\r\n\x1a\n"),
+	warcRecord("response", "https://example.com/shift-jis", "HTTP/1.1 200 OK\r\nContent-Type: text/html; charset=shift_jis\r\n\r\n"+
+		"<div itemscope itemtype=\"https://schema.org/Thing\"><span itemprop=\"name\">\x93\xfa\x96\x7b</span></div>"),
+}
+
+func TestParseWARC(t *testing.T) {
+	plain := strings.Join(warcRecords, "")
+
+	var compressed bytes.Buffer
+	for _, record := range warcRecords {
+		zw := gzip.NewWriter(&compressed)
+		_, _ = zw.Write([]byte(record))
+		_ = zw.Close()
+	}
+
+	for _, input := range [][]byte{[]byte(plain), compressed.Bytes()} {
+		var records []Record
+		err := ParseWARC(bytes.NewReader(input), func(r Record) {
+			records = append(records, r)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(records) != 2 {
+			t.Fatalf("Result should have been 2 records, but it was %d", len(records))
+		}
+
+		record := records[0]
+		if record.Err != nil {
+			t.Fatal(record.Err)
+		}
+		if record.URL != "https://example.com/person" || record.StatusCode != 200 || record.Date.Year() != 2024 {
+			t.Errorf("Result should have been the person record, but it was %+v", record)
+		}
+		if result := record.Microdata.Items[0].Properties["url"][0].(string); result != "https://example.com/penelope" {
+			t.Errorf("Result should have been \"https://example.com/penelope\", but it was \"%s\"", result)
+		}
+
+		if result := records[1].Microdata.Items[0].Properties["name"][0].(string); result != "日本" {
+			t.Errorf("Result should have been \"日本\", but it was \"%s\"", result)
+		}
+	}
+}
+
+func TestParseWARCMalformed(t *testing.T) {
+	err := ParseWARC(strings.NewReader("HTTP/1.1 200 OK\r\n\r\n"), func(r Record) {})
+	if err == nil {
+		t.Error("Result should have been an error for a file that is not a WARC")
+	}
+}